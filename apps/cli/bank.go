@@ -0,0 +1,90 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"pkg/database"
+	pl "pkg/plario"
+	"strings"
+
+	"github.com/fatih/color"
+)
+
+var bankCommands = map[string]func(args []string) error{
+	"search": bankSearch,
+	"export": bankExport,
+	"import": bankImport,
+}
+
+// question bank tools, bank <command> [flags]
+func BankCommand(args []string) error {
+	if len(args) == 0 || bankCommands[args[0]] == nil {
+		return fmt.Errorf("usage: bank search <query> -db <path> | bank export -db <path> [-o <file>] | bank import <file> -db <path>")
+	}
+	return bankCommands[args[0]](args[1:])
+}
+
+func bankSearch(args []string) error {
+	fs := newFlagSet("bank search")
+	dbPath := fs.String("db", "", "required: path to sqlite question bank")
+	subject := fs.Int("subject", 0, "optional: only questions of subject_id")
+	course := fs.Int("course", 0, "optional: only questions of course_id")
+	module := fs.Int("module", 0, "optional: only questions of module_id")
+	limit := fs.Int("limit", 20, "optional: maximum number of questions shown")
+
+	words := parseInterspersed(fs, args)
+
+	if *dbPath == "" || len(words) == 0 {
+		fs.Usage()
+		return fmt.Errorf("-db and query are required")
+	}
+
+	ctx := context.Background()
+	db, err := database.New(ctx, *dbPath)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	hits, err := db.SearchBank(ctx, database.SearchQuery{
+		Text:      strings.Join(words, " "),
+		SubjectID: *subject,
+		CourseID:  *course,
+		ModuleID:  *module,
+		Limit:     *limit,
+	})
+	if err != nil {
+		return err
+	}
+	if len(hits) == 0 {
+		fmt.Println("nothing found")
+		return nil
+	}
+
+	for _, h := range hits {
+		module := h.ModuleName
+		if module == "" {
+			module = fmt.Sprintf("module %d", h.ModuleID)
+		}
+		color.New(color.FgYellow).Printf("#%d", h.ID)
+		fmt.Printf("  %s (c_id %d, m_id %d)\n", module, h.CourseID, h.ModuleID)
+		fmt.Printf("%s\n", pl.LatexToUnicode(h.QuestionText))
+
+		correct := h.CorrectIDs()
+		for _, o := range h.Options {
+			for _, id := range correct {
+				if o.ID == id {
+					color.New(color.FgGreen).Printf("  ✓ %s\n", pl.LatexToUnicode(oneLine(pl.HTMLToMarkdown(o.Text))))
+				}
+			}
+		}
+		if len(correct) == 0 {
+			fmt.Println("  right answer is not known")
+		}
+		if h.Snippet != "" {
+			fmt.Printf("  … %s\n", oneLine(h.Snippet))
+		}
+		fmt.Println()
+	}
+	return nil
+}
@@ -0,0 +1,315 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"pkg/database"
+	pl "pkg/plario"
+	"pkg/prompt"
+	"pkg/solver"
+	"pkg/storage"
+	"strconv"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/rodaine/table"
+)
+
+const (
+	formatJSONL = "jsonl"
+	formatCSV   = "csv"
+)
+
+// bankRecord is one exercise of an exported bank and one line of JSON Lines,
+// content and option texts are html as the platform sends them
+type bankRecord struct {
+	ID        int          `json:"id"`
+	SubjectID int          `json:"subject_id"`
+	CourseID  int          `json:"course_id"`
+	ModuleID  int          `json:"module_id"`
+	Content   string       `json:"content"`
+	Options   []bankOption `json:"options"`
+}
+
+type bankOption struct {
+	ID   int    `json:"id"`
+	Text string `json:"text"`
+	// Correct is null while it is not known
+	Correct *bool `json:"correct"`
+}
+
+// csv has a row per option, exercise columns repeat on each of them
+var bankCSVHeader = []string{"exercise_id", "subject_id", "course_id", "module_id", "content", "answer_id", "answer_text", "correct"}
+
+func newBankRecord(e storage.Exercise) bankRecord {
+	r := bankRecord{ID: e.ID, SubjectID: e.SubjectID, CourseID: e.CourseID, ModuleID: e.ModuleID, Content: e.Content, Options: []bankOption{}}
+	for _, o := range e.Options {
+		r.Options = append(r.Options, bankOption{ID: o.ID, Text: o.Text, Correct: o.Correct})
+	}
+	return r
+}
+
+// exercise restores fingerprint and search text, they are not exported
+func (r bankRecord) exercise() storage.Exercise {
+	ex := &pl.Exercise{ActivityID: r.ID, Content: r.Content}
+	for _, o := range r.Options {
+		ex.PossibleAnswers = append(ex.PossibleAnswers, pl.PossibleAnswer{AnswerID: o.ID, Text: o.Text})
+	}
+	e := solver.BankExercise(r.data(), ex)
+	for i, o := range r.Options {
+		e.Options[i].Correct = o.Correct
+	}
+	return e
+}
+
+// catalog ids of the exercise, names are not exported
+func (r bankRecord) data() prompt.Data {
+	return prompt.Data{
+		Subject: prompt.Named{ID: r.SubjectID},
+		Course:  prompt.Named{ID: r.CourseID},
+		Module:  prompt.Named{ID: r.ModuleID},
+	}
+}
+
+// format from -format or file extension, json lines by default
+func bankFormat(format, path string) (string, error) {
+	switch format {
+	case formatJSONL, formatCSV:
+		return format, nil
+	case "":
+		if strings.EqualFold(filepath.Ext(path), ".csv") {
+			return formatCSV, nil
+		}
+		return formatJSONL, nil
+	}
+	return "", fmt.Errorf("unknown format %q, use %s or %s", format, formatJSONL, formatCSV)
+}
+
+func writeBank(w io.Writer, format string, records []bankRecord) error {
+	if format == formatJSONL {
+		enc := json.NewEncoder(w)
+		enc.SetEscapeHTML(false)
+		for _, r := range records {
+			if err := enc.Encode(r); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	cw := csv.NewWriter(w)
+	cw.Write(bankCSVHeader)
+	for _, r := range records {
+		row := []string{strconv.Itoa(r.ID), strconv.Itoa(r.SubjectID), strconv.Itoa(r.CourseID), strconv.Itoa(r.ModuleID), r.Content}
+		if len(r.Options) == 0 {
+			cw.Write(append(row, "", "", ""))
+		}
+		for _, o := range r.Options {
+			correct := ""
+			if o.Correct != nil {
+				correct = strconv.FormatBool(*o.Correct)
+			}
+			cw.Write(append(row[:5:5], strconv.Itoa(o.ID), o.Text, correct))
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func readBank(r io.Reader, format string) ([]bankRecord, error) {
+	if format == formatJSONL {
+		var records []bankRecord
+		sc := bufio.NewScanner(r)
+		sc.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+		for line := 1; sc.Scan(); line++ {
+			if strings.TrimSpace(sc.Text()) == "" {
+				continue
+			}
+			var rec bankRecord
+			if err := json.Unmarshal(sc.Bytes(), &rec); err != nil {
+				return nil, fmt.Errorf("line %d: %s", line, err)
+			}
+			if rec.ID <= 0 {
+				return nil, fmt.Errorf("line %d: exercise id is required", line)
+			}
+			records = append(records, rec)
+		}
+		return records, sc.Err()
+	}
+
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = len(bankCSVHeader)
+	rows, err := cr.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 || strings.Join(rows[0], ",") != strings.Join(bankCSVHeader, ",") {
+		return nil, fmt.Errorf("csv header must be %s", strings.Join(bankCSVHeader, ","))
+	}
+
+	var records []bankRecord
+	index := make(map[int]int)
+	for n, row := range rows[1:] {
+		line := n + 2
+		var ids [4]int
+		for i := range ids {
+			if ids[i], err = strconv.Atoi(row[i]); err != nil {
+				return nil, fmt.Errorf("line %d: %s: %s", line, bankCSVHeader[i], err)
+			}
+		}
+		if ids[0] <= 0 {
+			return nil, fmt.Errorf("line %d: exercise id is required", line)
+		}
+
+		i, ok := index[ids[0]]
+		if !ok {
+			i = len(records)
+			index[ids[0]] = i
+			records = append(records, bankRecord{ID: ids[0], SubjectID: ids[1], CourseID: ids[2], ModuleID: ids[3], Content: row[4], Options: []bankOption{}})
+		}
+		if row[5] == "" {
+			continue
+		}
+
+		o := bankOption{Text: row[6]}
+		if o.ID, err = strconv.Atoi(row[5]); err != nil {
+			return nil, fmt.Errorf("line %d: answer_id: %s", line, err)
+		}
+		if row[7] != "" {
+			correct, err := strconv.ParseBool(row[7])
+			if err != nil {
+				return nil, fmt.Errorf("line %d: correct: %s", line, err)
+			}
+			o.Correct = &correct
+		}
+		records[i].Options = append(records[i].Options, o)
+	}
+	return records, nil
+}
+
+func bankExport(args []string) error {
+	fs := newFlagSet("bank export")
+	dbPath := fs.String("db", "", "required: path to sqlite question bank")
+	out := fs.String("o", "", "optional: output file, stdout when empty")
+	format := fs.String("format", "", "optional: jsonl or csv, by -o extension when empty, jsonl by default")
+	verified := fs.Bool("verified", false, "optional: only exercises with known right answers")
+	fs.Parse(args)
+
+	if *dbPath == "" {
+		fs.Usage()
+		return fmt.Errorf("-db is required")
+	}
+	f, err := bankFormat(*format, *out)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	db, err := database.New(ctx, *dbPath)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	exercises, err := db.AllExercises(ctx)
+	if err != nil {
+		return err
+	}
+	var records []bankRecord
+	for _, e := range exercises {
+		if *verified && len(e.CorrectIDs()) == 0 {
+			continue
+		}
+		records = append(records, newBankRecord(e))
+	}
+
+	if *out == "" {
+		return writeBank(os.Stdout, f, records)
+	}
+	file, err := os.Create(*out)
+	if err != nil {
+		return err
+	}
+	if err := writeBank(file, f, records); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "exported %d exercises to %s\n", len(records), *out)
+	return nil
+}
+
+func bankImport(args []string) error {
+	fs := newFlagSet("bank import")
+	dbPath := fs.String("db", "", "required: path to sqlite question bank")
+	format := fs.String("format", "", "optional: jsonl or csv, by file extension when empty, jsonl by default")
+
+	files := parseInterspersed(fs, args)
+
+	if *dbPath == "" || len(files) != 1 {
+		fs.Usage()
+		return fmt.Errorf("-db and a file (- for stdin) are required")
+	}
+	f, err := bankFormat(*format, files[0])
+	if err != nil {
+		return err
+	}
+
+	in := os.Stdin
+	if files[0] != "-" {
+		if in, err = os.Open(files[0]); err != nil {
+			return err
+		}
+		defer in.Close()
+	}
+	records, err := readBank(in, f)
+	if err != nil {
+		return fmt.Errorf("%s: %s", files[0], err)
+	}
+
+	ctx := context.Background()
+	db, err := database.New(ctx, *dbPath)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	counts := make(map[string]int)
+	var conflicts []storage.Conflict
+	for _, r := range records {
+		outcome, conflict, err := storage.Merge(ctx, db, r.exercise())
+		if err != nil {
+			return fmt.Errorf("exercise %d: %s", r.ID, err)
+		}
+		counts[outcome]++
+		if conflict != nil {
+			conflicts = append(conflicts, *conflict)
+		}
+	}
+
+	fmt.Printf("added %d, updated %d, unchanged %d, conflicts %d\n",
+		counts[storage.MergeAdded], counts[storage.MergeUpdated], counts[storage.MergeUnchanged], counts[storage.MergeConflict])
+	if len(conflicts) == 0 {
+		return nil
+	}
+
+	headerFmt := color.New(color.FgWhite, color.Underline, color.Bold, color.Underline).SprintfFunc()
+	columnFmt := color.New(color.FgYellow).SprintfFunc()
+
+	fmt.Println("\nstored answers are kept for conflicting exercises:")
+	t := table.New("id", "stored", "imported")
+	t.WithHeaderFormatter(headerFmt).WithFirstColumnFormatter(columnFmt)
+	for _, c := range conflicts {
+		t.AddRow(c.ExerciseID, fmt.Sprint(c.Stored), fmt.Sprint(c.Imported))
+	}
+	t.Print()
+	return nil
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func testBank() []bankRecord {
+	yes, no := true, false
+	return []bankRecord{
+		{
+			ID: 1, SubjectID: 2, CourseID: 3, ModuleID: 4,
+			Content: "<p>Сколько будет \"2, 2\"?\nОтвет: <b>x</b></p>",
+			Options: []bankOption{
+				{ID: 10, Text: "<p>4</p>", Correct: &yes},
+				{ID: 11, Text: "<p>5, или 6</p>", Correct: &no},
+				{ID: 12, Text: "<p>\"7\"</p>"},
+			},
+		},
+		{ID: 5, SubjectID: 2, CourseID: 3, ModuleID: 4, Content: "<p>без вариантов</p>", Options: []bankOption{}},
+	}
+}
+
+func TestBankRoundTrip(t *testing.T) {
+	for _, format := range []string{formatJSONL, formatCSV} {
+		t.Run(format, func(t *testing.T) {
+			var b bytes.Buffer
+			if err := writeBank(&b, format, testBank()); err != nil {
+				t.Fatal(err)
+			}
+			records, err := readBank(&b, format)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(records, testBank()) {
+				t.Errorf("read back %+v, want %+v", records, testBank())
+			}
+		})
+	}
+}
+
+func TestReadBankErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		format string
+		input  string
+		err    string
+	}{
+		{"jsonl syntax", formatJSONL, "{\"id\": 1}\n{", "line 2"},
+		{"jsonl id", formatJSONL, "{\"content\": \"x\"}\n", "exercise id is required"},
+		{"csv header", formatCSV, "id,subject,course,module,content,answer,text,correct\n", "csv header"},
+		{"csv id", formatCSV, strings.Join(bankCSVHeader, ",") + "\n0,1,1,1,x,,,\n", "line 2: exercise id is required"},
+		{"csv correct", formatCSV, strings.Join(bankCSVHeader, ",") + "\n1,1,1,1,x,10,a,maybe\n", "line 2: correct"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := readBank(strings.NewReader(tt.input), tt.format)
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("error %v, want one containing %q", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestBankFormat(t *testing.T) {
+	tests := []struct {
+		format, path, want string
+	}{
+		{"", "", formatJSONL},
+		{"", "bank.CSV", formatCSV},
+		{"", "bank.jsonl", formatJSONL},
+		{formatJSONL, "bank.csv", formatJSONL},
+	}
+	for _, tt := range tests {
+		got, err := bankFormat(tt.format, tt.path)
+		if err != nil || got != tt.want {
+			t.Errorf("bankFormat(%q, %q) = %q, %v, want %q", tt.format, tt.path, got, err, tt.want)
+		}
+	}
+	if _, err := bankFormat("xml", ""); err == nil {
+		t.Error("unknown format accepted")
+	}
+}
+
+func TestBankRecordExercise(t *testing.T) {
+	r := testBank()[0]
+	e := r.exercise()
+	if e.Fingerprint == "" || e.QuestionText == "" || e.AnswersText == "" {
+		t.Errorf("fingerprint and search text not restored: %+v", e)
+	}
+	if ids := e.CorrectIDs(); !reflect.DeepEqual(ids, []int{10}) {
+		t.Errorf("correct %v, want [10]", ids)
+	}
+	if e.Options[2].Correct != nil {
+		t.Errorf("unknown option became %v", *e.Options[2].Correct)
+	}
+	if back := newBankRecord(e); !reflect.DeepEqual(back, r) {
+		t.Errorf("record %+v, want %+v", back, r)
+	}
+}
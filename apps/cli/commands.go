@@ -0,0 +1,66 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// subcommands are dispatched by the first positional argument,
+// everything else falls through to the quiz run loop
+var commands = map[string]func(args []string) error{
+	"models":    ModelsCommand,
+	"bank":      BankCommand,
+	"db":        DBCommand,
+	"decisions": DecisionsCommand,
+	"export":    ExportCommand,
+	"mistakes":  MistakesCommand,
+	"review":    ReviewCommand,
+	"runs":      RunsCommand,
+	"sync":      SyncCommand,
+}
+
+func isCommand(args []string) bool {
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") {
+		return false
+	}
+	_, ok := commands[args[0]]
+	return ok
+}
+
+func runCommand(args []string) {
+	if err := commands[args[0]](args[1:]); err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %s\n", args[0], err)
+		os.Exit(1)
+	}
+}
+
+func commandNames() []string {
+	names := make([]string, 0, len(commands))
+	for n := range commands {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func newFlagSet(name string) *flag.FlagSet {
+	return flag.NewFlagSet(name, flag.ExitOnError)
+}
+
+// parseInterspersed parses flags standing before, after or between
+// positional arguments and returns the positional ones
+func parseInterspersed(fs *flag.FlagSet, args []string) []string {
+	var positional []string
+	for {
+		fs.Parse(args)
+		args = fs.Args()
+		if len(args) == 0 {
+			return positional
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"pkg/database"
+	"pkg/storage"
+
+	"github.com/fatih/color"
+	"github.com/rodaine/table"
+)
+
+// openStore opens the question bank at path with pending migrations applied,
+// an empty path gives a store that lives only for the run
+func openStore(ctx context.Context, path string) (storage.Store, func() error, error) {
+	if path == "" {
+		return storage.NewMemory(), func() error { return nil }, nil
+	}
+	db, err := database.New(ctx, path)
+	if err != nil {
+		return nil, nil, err
+	}
+	return db, db.Close, nil
+}
+
+// schema migrations of the question bank, db migrate|status -db <path>
+func DBCommand(args []string) error {
+	if len(args) == 0 || (args[0] != "migrate" && args[0] != "status") {
+		return fmt.Errorf("usage: db migrate|status -db <path>")
+	}
+	action := args[0]
+
+	fs := newFlagSet("db " + action)
+	dbPath := fs.String("db", "", "required: path to sqlite question bank")
+	fs.Parse(args[1:])
+
+	if *dbPath == "" {
+		fs.Usage()
+		return fmt.Errorf("-db is required")
+	}
+
+	db, err := database.Open(context.Background(), *dbPath)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	if action == "migrate" {
+		applied, err := db.Migrate()
+		for _, m := range applied {
+			fmt.Printf("applied %04d_%s\n", m.Version, m.Name)
+		}
+		if err != nil {
+			return err
+		}
+		if len(applied) == 0 {
+			fmt.Println("nothing to migrate")
+		}
+	}
+
+	return printMigrations(db)
+}
+
+func printMigrations(db *database.DB) error {
+	version, err := db.Version()
+	if err != nil {
+		return err
+	}
+	migrations, err := database.Migrations()
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("schema version %d, latest %d\n", version, len(migrations))
+
+	headerFmt := color.New(color.FgWhite, color.Underline, color.Bold, color.Underline).SprintfFunc()
+	columnFmt := color.New(color.FgYellow).SprintfFunc()
+
+	t := table.New("version", "name", "status")
+	t.WithHeaderFormatter(headerFmt).WithFirstColumnFormatter(columnFmt)
+
+	for _, m := range migrations {
+		status := "pending"
+		if m.Version <= version {
+			status = "applied"
+		}
+		t.AddRow(m.Version, m.Name, status)
+	}
+	t.Print()
+	return nil
+}
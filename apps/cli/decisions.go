@@ -0,0 +1,48 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"pkg/database"
+
+	"github.com/fatih/color"
+	"github.com/rodaine/table"
+)
+
+// list learner decisions from confirmation mode
+func DecisionsCommand(args []string) error {
+	fs := newFlagSet("decisions")
+	dbPath := fs.String("db", "", "required: path to sqlite question bank")
+	module := fs.Int("module", 0, "optional: only decisions of module_id")
+	all := fs.Bool("all", false, "optional: also list accepted answers, by default only disagreements are shown")
+	fs.Parse(args)
+
+	if *dbPath == "" {
+		fs.Usage()
+		return fmt.Errorf("-db is required")
+	}
+
+	ctx := context.Background()
+	db, err := database.New(ctx, *dbPath)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	decisions, err := db.ListDecisions(ctx, *module, !*all)
+	if err != nil {
+		return err
+	}
+
+	headerFmt := color.New(color.FgWhite, color.Underline, color.Bold, color.Underline).SprintfFunc()
+	columnFmt := color.New(color.FgYellow).SprintfFunc()
+
+	t := table.New("time", "c_id", "m_id", "q_id", "model", "model_answer", "human_answer", "action")
+	t.WithHeaderFormatter(headerFmt).WithFirstColumnFormatter(columnFmt)
+
+	for _, d := range decisions {
+		t.AddRow(d.CreatedAt.Local().Format("2006-01-02 15:04"), d.CourseID, d.ModuleID, d.QuestionID, d.Model, d.ModelAnswer, d.HumanAnswer, d.Action)
+	}
+	t.Print()
+	return nil
+}
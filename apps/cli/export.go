@@ -0,0 +1,185 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"html"
+	"io"
+	"os"
+	"pkg/database"
+	"pkg/plario/content"
+	"pkg/storage"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var exportCommands = map[string]func(args []string) error{
+	"anki": exportAnki,
+}
+
+// question bank for other tools, export <format> [flags]
+func ExportCommand(args []string) error {
+	if len(args) == 0 || exportCommands[args[0]] == nil {
+		return fmt.Errorf("usage: export anki -db <path> [-o <file>]")
+	}
+	return exportCommands[args[0]](args[1:])
+}
+
+func exportAnki(args []string) error {
+	fs := newFlagSet("export anki")
+	dbPath := fs.String("db", "", "required: path to sqlite question bank")
+	out := fs.String("o", "", "optional: write deck to file instead of stdout")
+	deck := fs.String("deck", "Plario", "optional: anki deck the notes go to")
+	subject := fs.Int("subject", 0, "optional: only questions of subject_id")
+	course := fs.Int("course", 0, "optional: only questions of course_id")
+	module := fs.Int("module", 0, "optional: only questions of module_id")
+	fs.Parse(args)
+
+	if *dbPath == "" {
+		fs.Usage()
+		return fmt.Errorf("-db is required")
+	}
+
+	ctx := context.Background()
+	db, err := database.New(ctx, *dbPath)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	exercises, err := db.ListExercises(ctx, *subject)
+	if err != nil {
+		return err
+	}
+	catalog, err := db.Catalog(ctx)
+	if err != nil {
+		return err
+	}
+	mistakes, err := db.ListMistakes(ctx, *module)
+	if err != nil {
+		return err
+	}
+	explanations := make(map[int]string)
+	for _, m := range mistakes {
+		if m.Explanation != "" {
+			explanations[m.QuestionID] = m.Explanation
+		}
+	}
+
+	var notes []storage.Exercise
+	for _, e := range exercises {
+		if (*course == 0 || e.CourseID == *course) && (*module == 0 || e.ModuleID == *module) {
+			notes = append(notes, e)
+		}
+	}
+
+	var w io.Writer = os.Stdout
+	if *out != "" {
+		f, err := os.Create(*out)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+
+	if err := writeAnki(w, *deck, notes, explanations, ankiTagger(catalog)); err != nil {
+		return err
+	}
+	if *out != "" {
+		fmt.Fprintf(os.Stderr, "exported %d notes to %s\n", len(notes), *out)
+	}
+	return nil
+}
+
+// writeAnki writes anki text import: header lines describe the Basic note
+// type, deck and columns, guid keeps notes updated on a repeated import
+func writeAnki(w io.Writer, deck string, exercises []storage.Exercise, explanations map[int]string, tags func(storage.Exercise) string) error {
+	header := []string{
+		"#separator:tab",
+		"#html:true",
+		"#notetype:Basic",
+		"#deck:" + deck,
+		"#columns:guid\tFront\tBack\tTags",
+		"#guid column:1",
+		"#tags column:4",
+	}
+	if _, err := io.WriteString(w, strings.Join(header, "\n")+"\n"); err != nil {
+		return err
+	}
+
+	cw := csv.NewWriter(w)
+	cw.Comma = '\t'
+	for _, e := range exercises {
+		var front, back strings.Builder
+		front.WriteString(content.Parse(e.Content).HTML())
+		if len(e.Options) > 0 {
+			front.WriteString(`<ol type="A">`)
+			for _, o := range e.Options {
+				front.WriteString("<li>" + content.Parse(o.Text).HTML() + "</li>")
+			}
+			front.WriteString("</ol>")
+		}
+
+		for _, o := range e.Options {
+			if o.Correct != nil && *o.Correct {
+				back.WriteString("<div>✓ " + content.Parse(o.Text).HTML() + "</div>")
+			}
+		}
+		if s := explanations[e.ID]; s != "" {
+			back.WriteString("<hr>" + mathJax(s))
+		}
+
+		cw.Write([]string{"plario-" + strconv.Itoa(e.ID), front.String(), back.String(), tags(e)})
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+var (
+	displayMath = regexp.MustCompile(`(?s)\$\$(.+?)\$\$`)
+	inlineMath  = regexp.MustCompile(`\$([^$\n]+?)\$`)
+)
+
+// mathJax turns markdown of model explanations into html with $…$ math
+// replaced by delimiters anki renders
+func mathJax(s string) string {
+	s = html.EscapeString(s)
+	s = displayMath.ReplaceAllString(s, `\[$1\]`)
+	s = inlineMath.ReplaceAllString(s, `\($1\)`)
+	return strings.ReplaceAll(s, "\n", "<br>")
+}
+
+// ankiTagger tags a note with one hierarchical tag plario::subject::course::module,
+// ids stand in for names missing from the catalog
+func ankiTagger(c storage.Catalog) func(storage.Exercise) string {
+	names := func(entries []storage.CatalogEntry) map[int]string {
+		m := make(map[int]string, len(entries))
+		for _, e := range entries {
+			m[e.ID] = e.Name
+		}
+		return m
+	}
+	subjects, courses, modules := names(c.Subjects), names(c.Courses), names(c.Modules)
+
+	return func(e storage.Exercise) string {
+		parts := []string{
+			"plario",
+			ankiTag(subjects[e.SubjectID], "subject", e.SubjectID),
+			ankiTag(courses[e.CourseID], "course", e.CourseID),
+			ankiTag(modules[e.ModuleID], "module", e.ModuleID),
+		}
+		return strings.Join(parts, "::")
+	}
+}
+
+// anki splits tags on spaces and levels on ::
+func ankiTag(name, kind string, id int) string {
+	name = strings.Join(strings.Fields(strings.ReplaceAll(name, "::", ":")), "_")
+	if name == "" {
+		return kind + "_" + strconv.Itoa(id)
+	}
+	return name
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	pl "pkg/plario"
+	"pkg/solver"
+	"pkg/storage"
+	"strconv"
+	"strings"
+
+	"github.com/fatih/color"
+)
+
+var (
+	stdin = bufio.NewReader(os.Stdin)
+
+	errQuit = errors.New("quit requested")
+)
+
+// commands start with characters option labels do not have, options H, S or
+// Q must stay selectable by their letter
+const (
+	cmdHint = "?"
+	cmdSkip = ":s"
+	cmdQuit = ":q"
+)
+
+// readLine prints prompt and returns trimmed line from stdin
+func readLine(prompt string) (string, error) {
+	fmt.Print(prompt)
+	line, err := stdin.ReadString('\n')
+	if err != nil && line == "" {
+		return "", err
+	}
+	return strings.TrimSpace(line), nil
+}
+
+func optionLabel(i int) string {
+	if i < 26 {
+		return string(rune('A' + i))
+	}
+	return "A" + strconv.Itoa(i)
+}
+
+// printExercise renders exercise for terminal, marked option gets an arrow
+func printExercise(ex *pl.Exercise, marked int) {
+	quiz := ex.Quiz()
+	fmt.Printf("\n%s\n\n", pl.LatexToUnicode(quiz.Question))
+	for i, a := range quiz.Answers {
+		line := fmt.Sprintf("%s) %s", optionLabel(i), strings.ReplaceAll(pl.LatexToUnicode(a.Option), "\n", "\n     "))
+		if a.ID == marked {
+			color.New(color.FgGreen, color.Bold).Printf("  → %s\n", line)
+			continue
+		}
+		fmt.Printf("    %s\n", line)
+	}
+	fmt.Println()
+}
+
+// parseOption accepts option letter or raw answer id
+func parseOption(ex *pl.Exercise, s string) (int, bool) {
+	s = strings.ToUpper(strings.TrimSpace(s))
+	for i, a := range ex.PossibleAnswers {
+		if s == optionLabel(i) || s == strconv.Itoa(a.AnswerID) {
+			return a.AnswerID, true
+		}
+	}
+	return 0, false
+}
+
+func labelOf(ex *pl.Exercise, id int) string {
+	for i, a := range ex.PossibleAnswers {
+		if a.AnswerID == id {
+			return optionLabel(i)
+		}
+	}
+	return strconv.Itoa(id)
+}
+
+// AskHuman shows the exercise with model votes and reads the answer
+func AskHuman(ex *pl.Exercise, votes []solver.Vote) (int, error) {
+	printExercise(ex, 0)
+	for _, v := range votes {
+		fmt.Printf("  model votes for %s: %d\n", labelOf(ex, v.AnswerID), v.Count)
+	}
+
+	for {
+		line, err := readLine("answer: ")
+		if err != nil {
+			return 0, err
+		}
+		if id, ok := parseOption(ex, line); ok {
+			return id, nil
+		}
+		fmt.Println("no such option")
+	}
+}
+
+// proposal is what Confirm showed for an exercise the learner skipped
+type proposal struct {
+	result    *solver.Result
+	rationale string
+}
+
+// Confirm shows proposed answer with rationale and lets the learner accept it,
+// pick another option, skip the question or quit
+func Confirm(ex *pl.Exercise, proposed int, confidence float64, rationale string) (int, string, error) {
+	printExercise(ex, proposed)
+	fmt.Printf("model answer: %s (confidence %.0f%%)\n", labelOf(ex, proposed), confidence*100)
+	if rationale != "" {
+		fmt.Printf("%s\n", pl.LatexToUnicode(rationale))
+	}
+	fmt.Println()
+
+	for {
+		line, err := readLine("[enter] accept, <letter> choose, " + cmdSkip + " skip, " + cmdQuit + " quit: ")
+		if err != nil {
+			return 0, "", err
+		}
+
+		switch strings.ToLower(line) {
+		case "":
+			return proposed, storage.ActionAccepted, nil
+		case cmdSkip:
+			return 0, storage.ActionSkipped, nil
+		case cmdQuit:
+			return 0, "", errQuit
+		}
+
+		if id, ok := parseOption(ex, line); ok {
+			if id == proposed {
+				return id, storage.ActionAccepted, nil
+			}
+			return id, storage.ActionChanged, nil
+		}
+		fmt.Println("no such option")
+	}
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	pl "pkg/plario"
+	"pkg/prompt"
+	"pkg/storage"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// exercise with options A-Z, ids 100 and up
+func lettersExercise() *pl.Exercise {
+	ex := &pl.Exercise{ActivityID: 1, Content: "<p>?</p>"}
+	for i := range 26 {
+		ex.PossibleAnswers = append(ex.PossibleAnswers, pl.PossibleAnswer{AnswerID: 100 + i, Text: "<p>" + strconv.Itoa(i) + "</p>"})
+	}
+	return ex
+}
+
+func input(s string) {
+	stdin = bufio.NewReader(strings.NewReader(s))
+}
+
+func TestConfirm(t *testing.T) {
+	ex := lettersExercise()
+	tests := []struct {
+		in     string
+		want   int
+		action string
+		err    error
+	}{
+		{"\n", 100, storage.ActionAccepted, nil},
+		{"A\n", 100, storage.ActionAccepted, nil},
+		{"s\n", 118, storage.ActionChanged, nil},
+		{"Y\n", 124, storage.ActionChanged, nil},
+		{"q\n", 116, storage.ActionChanged, nil},
+		{":s\n", 0, storage.ActionSkipped, nil},
+		{":q\n", 0, "", errQuit},
+		{"zz\n:Q\n", 0, "", errQuit},
+	}
+	for _, tt := range tests {
+		input(tt.in)
+		id, action, err := Confirm(ex, 100, 1, "")
+		if !errors.Is(err, tt.err) || id != tt.want || action != tt.action {
+			t.Errorf("%q: got %d %q %v, want %d %q %v", tt.in, id, action, err, tt.want, tt.action, tt.err)
+		}
+	}
+}
+
+func TestStudyOptionH(t *testing.T) {
+	input("h\n")
+	id, err := Study(nil, nil, prompt.Data{}, lettersExercise())
+	if err != nil || id != 107 {
+		t.Errorf("got %d %v, want option H", id, err)
+	}
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"errors"
 	"flag"
 	"fmt"
 	"log/slog"
@@ -11,9 +12,12 @@ import (
 	"os/signal"
 	"pkg/llm"
 	pl "pkg/plario"
+	"pkg/prompt"
+	"pkg/retrieval"
+	"pkg/solver"
+	"pkg/storage"
 	"syscall"
 
-	"strconv"
 	"time"
 )
 
@@ -28,13 +32,47 @@ var (
 	masteryCap   float64
 	isMasteryCap bool
 
-	totalCorrent, totalWrong int
+	totalCorrent, totalWrong       int
+	totalDecisions                 = map[string]int{}
+	totalBankHits, totalBankMisses int
+	totalSkipped                   int
+
+	// run is id of the run in -db, exitReason is stored when it ends
+	run        int64
+	exitReason = storage.ExitInterrupted
+
+	promptsDir string
+
+	dbPath            string
+	examples          int
+	examplesThreshold float64
+	matchThreshold    float64
+
+	theoryChunks                     int
+	reasoning                        bool
+	cacheTTL                         time.Duration
+	biasCheck                        bool
+	samples                          int
+	temperature                      float64
+	ensemble                         string
+	minAgreement                     float64
+	lowAgreement                     string
+	escalateModel                    llm.Model
+	maxSkips                         int
+	confirmMode                      bool
+	studyMode                        bool
+	embedURL, embedModel, embedToken string
 
 	logLevel string
 	help     string
 )
 
 func main() {
+	if isCommand(os.Args[1:]) {
+		runCommand(os.Args[1:])
+		return
+	}
+
 	flag.StringVar(&plarioToken, "ptoken", "", "required: plario access token")
 	flag.StringVar(&groqToken, "gtoken", "", "required: groq api token")
 	flag.StringVar(&logLevel, "loglevel", "info", "optional: provide to change log level")
@@ -46,7 +84,29 @@ func main() {
 	flag.IntVar(&rMin, "rmin", 5, "optional: set minimum value for random delay between each question submission")
 	flag.IntVar(&rMax, "rmax", 10, "optional: set maximum value for random delay between each question submission")
 	flag.Var(&model, "model", "optional: choose from available groq models")
+	flag.StringVar(&promptsDir, "prompts", "", "optional: directory with prompt templates (module_<id>.tmpl, course_<id>.tmpl, subject_<id>.tmpl, default.tmpl)")
+	flag.StringVar(&dbPath, "db", "", "optional: path to sqlite question bank")
+	flag.IntVar(&examples, "examples", 3, "optional: number of similar verified questions from the bank attached as few-shot examples, 0 disables")
+	flag.Float64Var(&examplesThreshold, "examples_threshold", 0.3, "optional: minimum similarity [0, 1] for a question to become an example")
+	flag.Float64Var(&matchThreshold, "match_threshold", 0, "optional: minimum similarity [0, 1] of a near-duplicate with the same options from -db to answer with its verified answer, e.g. 0.95, 0 disables")
+	flag.IntVar(&theoryChunks, "theory", 3, "optional: number of relevant theory lesson chunks attached to each question, 0 disables")
+	flag.StringVar(&embedURL, "embed_url", "", "optional: base url of openai compatible embeddings api for theory search, bm25 is used if empty")
+	flag.StringVar(&embedModel, "embed_model", "text-embedding-3-small", "optional: embeddings model")
+	flag.StringVar(&embedToken, "embed_token", "", "optional: embeddings api token")
+	flag.BoolVar(&reasoning, "reasoning", false, "optional: let the model reason before answering, reasoning is stored in -db")
+	flag.DurationVar(&cacheTTL, "cache_ttl", 30*24*time.Hour, "optional: how long answers are cached in -db for repeated questions, 0 disables")
+	flag.BoolVar(&biasCheck, "bias_check", false, "optional: ask again with shuffled options, disagreeing answers are settled by -low_agreement")
+	flag.IntVar(&samples, "samples", 1, "optional: number of answers drawn from the model (or each -ensemble model) to vote on")
+	flag.Float64Var(&temperature, "temperature", -1, "optional: sampling temperature, provider default if negative")
+	flag.StringVar(&ensemble, "ensemble", "", "optional: comma separated models voting instead of -model")
+	flag.Float64Var(&minAgreement, "min_agreement", 0.5, "optional: minimum share of samples agreeing on the answer")
+	flag.StringVar(&lowAgreement, "low_agreement", solver.PolicySkip, "optional: what to do on ties or low agreement: escalate, skip or ask")
+	flag.Var(&escalateModel, "escalate_model", "optional: stronger model asked by -low_agreement escalate")
+	flag.IntVar(&maxSkips, "max_skips", 3, "optional: how many times an exercise may be left unanswered before the run stops, plario serves it again until it is answered")
+	flag.BoolVar(&confirmMode, "confirm", false, "optional: show each exercise with proposed answer and let the learner accept, change or skip it before submitting")
+	flag.BoolVar(&studyMode, "study", false, "optional: the learner answers on their own, the model gives hints on request and explains the solution after submitting")
 	flag.StringVar(&help, "help", "", "print out usage")
+	flag.Usage = usage
 	flag.Parse()
 
 	logger := InitLogger(logLevel)
@@ -63,6 +123,14 @@ func main() {
 		cancel()
 		logger.Info("Total correct", "count", totalCorrent)
 		logger.Info("Total wrong", "count", totalWrong)
+		logger.Info("Total skipped", "count", totalSkipped)
+		if !studyMode {
+			logger.Info("Total bank", "hits", totalBankHits, "misses", totalBankMisses)
+		}
+		if confirmMode {
+			logger.Info("Total decisions", "accepted", totalDecisions[storage.ActionAccepted],
+				"changed", totalDecisions[storage.ActionChanged], "skipped", totalDecisions[storage.ActionSkipped])
+		}
 	}()
 
 	if help != "" {
@@ -79,13 +147,49 @@ func main() {
 		isMasteryCap = true
 	}
 
-	if !model.IsValid() {
-		fmt.Printf("No such model, pick one from %v\n", llm.All())
+	r := rand.New(rand.NewSource(time.Now().UnixNano()))
+	client := &http.Client{}
+
+	catalog, err := llm.LoadCatalog(client, groqToken, llm.DefaultCatalogPath(), false)
+	ensembleModels := ParseModels(ensemble)
+	models := append([]llm.Model{model}, ensembleModels...)
+	if escalateModel != "" {
+		models = append(models, escalateModel)
+	}
+
+	switch {
+	case catalog == nil:
+		logger.Warn("could not load model catalog, skipping model validation", "message", err)
+	case err != nil:
+		logger.Warn("could not refresh model catalog, using cached", "fetched_at", catalog.FetchedAt, "message", err)
+		fallthrough
+	default:
+		for _, m := range models {
+			if err := catalog.Validate(m); err != nil {
+				fmt.Printf("%s, pick one from %v\n", err, catalog.ChatIDs())
+				os.Exit(1)
+			}
+		}
+	}
+
+	if !solver.ValidPolicy(lowAgreement) {
+		fmt.Printf("No such low agreement policy %q, pick one from %v\n", lowAgreement, []string{solver.PolicyEscalate, solver.PolicySkip, solver.PolicyAsk})
+		os.Exit(1)
+	}
+	if lowAgreement == solver.PolicyEscalate && escalateModel == "" {
+		fmt.Println("-low_agreement escalate needs -escalate_model")
+		os.Exit(1)
+	}
+	if maxSkips < 1 {
+		fmt.Println("-max_skips must be at least 1")
+		os.Exit(1)
+	}
+
+	if studyMode && confirmMode {
+		fmt.Println("-study and -confirm can not be used together")
 		os.Exit(1)
 	}
 
-	r := rand.New(rand.NewSource(time.Now().UnixNano()))
-	client := &http.Client{}
 	plario := pl.NewPlario(plarioToken, logger)
 
 	if infoMode {
@@ -105,23 +209,120 @@ func main() {
 		logger.Error(err.Error())
 	}
 
-	var cname string
-	for _, s := range subjects {
-		for _, c := range s.Courses {
-			if c.ID == courseID {
-				cname = c.Name
-			}
+	modules, err := plario.GetModules(client)
+	if err != nil {
+		logger.Error("p.GetModules", "message", err.Error())
+	}
+
+	promptData := PromptData(plario, subjects, modules, logger)
+	prompts := prompt.NewResolver(promptsDir)
+	_, promptSource, err := prompts.Resolve(promptData)
+	if err != nil {
+		logger.Error("prompts.Resolve", "message", err.Error())
+		os.Exit(1)
+	}
+	logger.Info("prompt template", "path", promptSource)
+
+	groq := NewGroq(model, catalog, logger)
+	solve := solver.New(groq, prompts, logger)
+	solve.Examples = examples
+	solve.Threshold = examplesThreshold
+	solve.MatchThreshold = matchThreshold
+	solve.TheoryChunks = theoryChunks
+	solve.Reasoning = reasoning
+	solve.CacheTTL = cacheTTL
+	solve.BiasCheck = biasCheck
+	solve.Samples = samples
+	solve.MinAgreement = minAgreement
+	solve.Policy = lowAgreement
+	solve.AskHuman = AskHuman
+	for _, m := range ensembleModels {
+		solve.Ensemble = append(solve.Ensemble, NewGroq(m, catalog, logger))
+	}
+	if escalateModel != "" {
+		solve.Escalate = NewGroq(escalateModel, catalog, logger)
+	}
+
+	// with -db the bank, runs and attempts outlive the process
+	store, closeStore, err := openStore(ctx, dbPath)
+	if err != nil {
+		logger.Error("openStore", "message", err.Error())
+		os.Exit(1)
+	}
+	defer closeStore()
+	solve.Bank = store
+
+	if dbPath != "" {
+		synced, err := Sync(ctx, plario, client, store, subjects)
+		if err != nil {
+			logger.Warn("catalog sync failed, storing current module only", "message", err.Error())
+		} else {
+			logger.Info("catalog synced", "added", synced.Added, "renamed", synced.Renamed)
 		}
 	}
+	// the module of the run must be there even if the api did not list it
+	if err := RegisterCatalog(ctx, store, promptData); err != nil {
+		logger.Error("RegisterCatalog", "message", err.Error())
+		os.Exit(1)
+	}
 
-	groq := llm.NewGroq(
-		groqToken,
-		model,
-		fmt.Sprintf("You are solving a test on a subject of %s in russian. You will receive question and possible answers, it is in latex format. Only return id of correct answer, never return reasoning or any text data.", cname),
-		logger,
-	)
+	run, err = store.StartRun(ctx, storage.Run{
+		SubjectID: plario.SubjectID,
+		CourseID:  plario.CourseID,
+		ModuleID:  plario.ModuleID,
+		Model:     string(model),
+		Flags:     RunFlags(),
+	})
+	if err != nil {
+		logger.Error("store.StartRun", "message", err.Error())
+		os.Exit(1)
+	}
+	defer func() {
+		// ctx may already be canceled, finishing the run must not be
+		err := store.FinishRun(context.Background(), storage.Run{
+			ID:         run,
+			ExitReason: exitReason,
+			Correct:    totalCorrent,
+			Wrong:      totalWrong,
+			BankHits:   totalBankHits,
+			BankMisses: totalBankMisses,
+			Skipped:    totalSkipped,
+		})
+		if err != nil {
+			logger.Error("store.FinishRun", "message", err.Error())
+		}
+	}()
 
-	logger.Info(groq.Instructions)
+	for _, m := range modules {
+		if m.ID == plario.ModuleID {
+			if err := store.CreateMasterySample(ctx, storage.MasterySample{RunID: run, ModuleID: m.ID, Mastery: m.Mastery}); err != nil {
+				logger.Warn("store.CreateMasterySample", "message", err.Error())
+			}
+		}
+	}
+
+	var embedder retrieval.Embedder
+	if embedURL != "" {
+		embedder = retrieval.NewOpenAIEmbedder(embedURL, embedModel, embedToken)
+	}
+	solve.Theory = retrieval.NewIndex(embedder, store, logger)
+
+	skips := newSkipTracker(maxSkips)
+	// leave leaves exercise id unanswered, the run stops once it was left too
+	// often, otherwise it waits for plario to serve the exercise again
+	leave := func(id int, log *slog.Logger, sleep int) {
+		totalSkipped++
+		if skips.skip(id) {
+			log.Error("exercise left unanswered too many times, stopping", "max_skips", maxSkips)
+			exitReason = storage.ExitSkipped
+			cancel()
+			return
+		}
+		time.Sleep(time.Duration(sleep) * time.Second)
+	}
+	// proposals skipped by the learner in -confirm are shown again as they
+	// were instead of solving the exercise again
+	proposals := make(map[int]proposal)
 	for {
 		select {
 		case <-ctx.Done():
@@ -129,6 +330,10 @@ func main() {
 			return
 		default:
 			randomSleep := RandInRange(r, rMin, rMax)
+			if studyMode {
+				// the learner sets the pace
+				randomSleep = 0
+			}
 
 			question, err := plario.GetQuestion(client)
 			if err != nil {
@@ -152,6 +357,10 @@ func main() {
 
 			if len(question.Exercise.PossibleAnswers) == 0 {
 				withMeta.Info("no answers in response, probably a theory, submitting")
+				text := pl.HTMLToMarkdown(question.Exercise.Content)
+				if err := solve.Theory.Add(ctx, client, plario.CourseID, plario.ModuleID, question.Exercise.ActivityID, text); err != nil {
+					withMeta.Warn("theory.Add", "message", err.Error())
+				}
 				err := plario.CompleteLesson(client, question.Exercise.ActivityID)
 				if err != nil {
 					withMeta.Error(err.Error())
@@ -161,21 +370,95 @@ func main() {
 				continue
 			}
 
-			groqResponse, err := groq.SendGroqRequest(client, question.Exercise.ToString())
-			if err != nil {
-				withMeta.Error(err.Error())
-				break
-			}
+			withMeta.Debug("exercise", "text", question.Exercise.Display(true))
 
-			if len(groqResponse.Choices) == 0 {
-				withMeta.Error("groq returned bad response", "message", err)
-				break
-			}
-
-			answer, err := strconv.Atoi(groqResponse.Choices[0].Message.Content)
-			if err != nil {
-				withMeta.Error("could not convert atoi", "message", err.Error())
-				break
+			var answer int
+			// source is who picked the submitted answer
+			source := string(model)
+			if studyMode {
+				source = storage.LearnerModel
+				answer, err = Study(client, solve, promptData, &question.Exercise)
+				if err != nil {
+					withMeta.Info("study stopped", "message", err.Error())
+					exitReason = storage.ExitQuit
+					cancel()
+					break
+				}
+			} else {
+				id := question.Exercise.ActivityID
+				result, rationale := proposals[id].result, proposals[id].rationale
+				if result == nil {
+					result, err = solve.Solve(ctx, client, promptData, &question.Exercise)
+					if errors.Is(err, solver.ErrPositionBias) || errors.Is(err, solver.ErrLowAgreement) {
+						// not submitting, the question comes back and is solved again
+						withMeta.Warn("answer is not reliable, not submitting", "message", err.Error())
+						leave(id, withMeta, randomSleep)
+						break
+					}
+					if err != nil {
+						withMeta.Error("solver.Solve", "message", err.Error())
+						break
+					}
+					if result.Banked {
+						totalBankHits++
+					} else {
+						totalBankMisses++
+					}
+					withMeta.Debug("solved", "answer", result.AnswerID, "banked", result.Banked, "match", result.Match, "matched_id", result.MatchedID, "match_score", result.MatchScore, "examples", result.Examples, "theory", result.Theory, "cached", result.Cached,
+						"confidence", result.Confidence, "votes", result.Votes, "escalated", result.Escalated, "human", result.Human)
+					if result.Flagged {
+						withMeta.Warn("answer changed with options order, settled by low agreement policy", "answers", result.BiasAnswers, "answer", result.AnswerID, "policy", lowAgreement)
+					}
+				} else {
+					withMeta.Info("exercise skipped by learner is served again")
+				}
+				answer = result.AnswerID
+
+				if confirmMode {
+					if rationale == "" {
+						rationale = result.Reasoning
+					}
+					if rationale == "" {
+						rationale, err = solve.Rationale(client, promptData, &question.Exercise, answer)
+						if err != nil {
+							withMeta.Warn("solver.Rationale", "message", err.Error())
+						}
+					}
+
+					chosen, action, err := Confirm(&question.Exercise, answer, result.Confidence, rationale)
+					if err != nil {
+						withMeta.Info("confirmation stopped", "message", err.Error())
+						exitReason = storage.ExitQuit
+						cancel()
+						break
+					}
+					totalDecisions[action]++
+
+					err = store.CreateDecision(ctx, storage.Decision{
+						QuestionID:  question.Exercise.ActivityID,
+						Model:       string(model),
+						ModelAnswer: answer,
+						HumanAnswer: chosen,
+						Action:      action,
+						CourseID:    plario.CourseID,
+						ModuleID:    plario.ModuleID,
+					})
+					if err != nil {
+						withMeta.Warn("store.CreateDecision", "message", err.Error())
+					}
+
+					if action == storage.ActionSkipped {
+						withMeta.Info("skipped by learner")
+						proposals[id] = proposal{result: result, rationale: rationale}
+						leave(id, withMeta, randomSleep)
+						break
+					}
+					delete(proposals, id)
+					answer = chosen
+					if action == storage.ActionChanged {
+						source = storage.LearnerModel
+					}
+				}
 			}
 
 			response, err := plario.PostAnswer(client, question.Exercise.ActivityID, []int{answer}, false)
@@ -198,6 +481,55 @@ func main() {
 				totalCorrent++
 			}
 
+			if err := solve.Remember(ctx, promptData, &question.Exercise, response.RightAnswerIDs); err != nil {
+				withMeta.Warn("solver.Remember", "message", err.Error())
+			} else {
+				err := store.CreateAttempt(ctx, storage.Attempt{
+					ExerciseID: question.Exercise.ActivityID,
+					Session:    plario.Attempt,
+					Model:      source,
+					ChosenIDs:  []int{answer},
+					CorrectIDs: response.RightAnswerIDs,
+					Correct:    storage.SameIDs([]int{answer}, response.RightAnswerIDs),
+				})
+				if err != nil {
+					withMeta.Warn("store.CreateAttempt", "message", err.Error())
+				}
+			}
+
+			// explanations are only shown in study mode or stored with a mistake
+			var explanation string
+			if (answer != rightAnswer && dbPath != "") || studyMode {
+				explanation, err = solve.Explain(client, promptData, &question.Exercise, answer, rightAnswer)
+				if err != nil {
+					withMeta.Warn("solver.Explain", "message", err.Error())
+				}
+			}
+
+			if answer != rightAnswer {
+				withMeta.Debug("mistake", "explanation", explanation)
+				quiz := question.Exercise.Quiz()
+				err := store.CreateMistake(ctx, storage.Mistake{
+					QuestionID:   question.Exercise.ActivityID,
+					Question:     quiz.Question,
+					ChosenAnswer: answer,
+					ChosenText:   quiz.AnswerText(answer),
+					RightAnswer:  rightAnswer,
+					RightText:    quiz.AnswerText(rightAnswer),
+					Explanation:  explanation,
+					Model:        source,
+					CourseID:     plario.CourseID,
+					ModuleID:     plario.ModuleID,
+				})
+				if err != nil {
+					withMeta.Warn("store.CreateMistake", "message", err.Error())
+				}
+			}
+
+			if studyMode {
+				Review(&question.Exercise, answer, rightAnswer, explanation)
+			}
+
 			ms, err := plario.GetModules(client)
 			if err != nil {
 				withMeta.Error("p.GetModules", "message", err.Error())
@@ -208,12 +540,17 @@ func main() {
 				if m.ID == plario.ModuleID {
 					currentMastery = m.Mastery
 					logger.Info("mastery", "value", slog.Float64Value(m.Mastery))
+					if err := store.CreateMasterySample(ctx, storage.MasterySample{RunID: run, ModuleID: m.ID, Mastery: m.Mastery}); err != nil {
+						withMeta.Warn("store.CreateMasterySample", "message", err.Error())
+					}
 					break
 				}
 			}
 
 			if isMasteryCap && currentMastery >= masteryCap {
 				logger.Info("mastery", "hit mastery cap", slog.Float64Value(currentMastery))
+				exitReason = storage.ExitMastery
+				cancel()
 				break
 			}
 			time.Sleep(time.Duration(randomSleep) * time.Second)
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"pkg/database"
+	"pkg/storage"
+	"strings"
+)
+
+// export mistake notebook as markdown for revision
+func MistakesCommand(args []string) error {
+	fs := newFlagSet("mistakes")
+	dbPath := fs.String("db", "", "required: path to sqlite question bank")
+	module := fs.Int("module", 0, "optional: only mistakes of module_id")
+	out := fs.String("o", "", "optional: write markdown to file instead of stdout")
+	fs.Parse(args)
+
+	if *dbPath == "" {
+		fs.Usage()
+		return fmt.Errorf("-db is required")
+	}
+
+	ctx := context.Background()
+	db, err := database.New(ctx, *dbPath)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	mistakes, err := db.ListMistakes(ctx, *module)
+	if err != nil {
+		return err
+	}
+
+	var w io.Writer = os.Stdout
+	if *out != "" {
+		f, err := os.Create(*out)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+
+	return writeMistakes(w, mistakes)
+}
+
+// writeMistakes writes a section per module, mistakes come sorted by module
+func writeMistakes(w io.Writer, mistakes []storage.Mistake) error {
+	var b strings.Builder
+	b.WriteString("# Mistakes\n")
+
+	module := -1
+	n := 0
+	for _, m := range mistakes {
+		if m.ModuleID != module {
+			module = m.ModuleID
+			n = 0
+			if m.ModuleName != "" {
+				fmt.Fprintf(&b, "\n## %s\n", m.ModuleName)
+			} else {
+				fmt.Fprintf(&b, "\n## Module %d\n", m.ModuleID)
+			}
+		}
+		n++
+
+		fmt.Fprintf(&b, "\n### %d. Question %d\n\n", n, m.QuestionID)
+		fmt.Fprintf(&b, "%s\n\n", strings.TrimSpace(m.Question))
+		fmt.Fprintf(&b, "- Chosen (%s): %s\n", m.Model, oneLine(m.ChosenText))
+		fmt.Fprintf(&b, "- Correct: %s\n", oneLine(m.RightText))
+		if m.Explanation != "" {
+			fmt.Fprintf(&b, "\n%s\n", strings.TrimSpace(m.Explanation))
+		}
+		fmt.Fprintf(&b, "\n*%s*\n", m.CreatedAt.Local().Format("2006-01-02 15:04"))
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func oneLine(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
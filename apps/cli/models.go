@@ -0,0 +1,73 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"pkg/llm"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/rodaine/table"
+)
+
+// list models from groq catalog with their capabilities
+func ModelsCommand(args []string) error {
+	fs := newFlagSet("models")
+	token := fs.String("gtoken", "", "required: groq api token")
+	refresh := fs.Bool("refresh", false, "optional: ignore cached catalog and fetch it again")
+	all := fs.Bool("all", false, "optional: also list classifiers and inactive models")
+	cachePath := fs.String("models_cache", llm.DefaultCatalogPath(), "optional: path to cached model catalog")
+	fs.Parse(args)
+
+	catalog, err := llm.LoadCatalog(&http.Client{}, *token, *cachePath, *refresh)
+	if catalog == nil {
+		return err
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "using cached catalog from %s: %s\n", catalog.FetchedAt.Format("2006-01-02 15:04"), err)
+	}
+
+	models := catalog.Chat()
+	if *all {
+		models = catalog.Models
+	}
+	printModelsTable(models)
+	return nil
+}
+
+func printModelsTable(models []llm.ModelInfo) {
+	headerFmt := color.New(color.FgWhite, color.Underline, color.Bold, color.Underline).SprintfFunc()
+	columnFmt := color.New(color.FgYellow).SprintfFunc()
+
+	t := table.New("id", "owned_by", "context", "max_output", "capabilities")
+	t.WithHeaderFormatter(headerFmt).WithFirstColumnFormatter(columnFmt)
+
+	for _, m := range models {
+		t.AddRow(m.ID, m.OwnedBy, m.ContextWindow, m.MaxCompletionTokens, capabilitiesString(m))
+	}
+	t.Print()
+}
+
+func capabilitiesString(m llm.ModelInfo) string {
+	var caps []string
+	if !m.Active {
+		caps = append(caps, "inactive")
+	}
+	c := m.Capabilities
+	for _, f := range []struct {
+		ok   bool
+		name string
+	}{
+		{c.Chat, "chat"},
+		{c.Classifier, "classifier"},
+		{c.Vision, "vision"},
+		{c.JSONMode, "json"},
+		{c.Reasoning, "reasoning"},
+	} {
+		if f.ok {
+			caps = append(caps, f.name)
+		}
+	}
+	return strings.Join(caps, ",")
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"pkg/database"
+	pl "pkg/plario"
+	"pkg/review"
+	"pkg/solver"
+	"pkg/storage"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+)
+
+// quiz the learner from the local question bank with spaced repetition,
+// nothing is sent to plario
+func ReviewCommand(args []string) error {
+	fs := newFlagSet("review")
+	dbPath := fs.String("db", "", "required: path to sqlite question bank")
+	subject := fs.Int("subject", 0, "optional: only questions of subject_id")
+	course := fs.Int("course", 0, "optional: only questions of course_id")
+	module := fs.Int("module", 0, "optional: only questions of module_id")
+	limit := fs.Int("limit", 20, "optional: maximum number of cards in the session")
+	newCards := fs.Int("new", 10, "optional: maximum number of never reviewed cards in the session")
+	fs.Parse(args)
+
+	if *dbPath == "" {
+		fs.Usage()
+		return fmt.Errorf("-db is required")
+	}
+
+	ctx := context.Background()
+	db, err := database.New(ctx, *dbPath)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	items, err := db.ReviewQueue(ctx, database.ReviewQuery{
+		SubjectID: *subject,
+		CourseID:  *course,
+		ModuleID:  *module,
+		Now:       time.Now(),
+		Limit:     *limit,
+		NewLimit:  *newCards,
+	})
+	if err != nil {
+		return err
+	}
+	if len(items) == 0 {
+		fmt.Println("nothing to review")
+		return printNextDue(ctx, db)
+	}
+
+	reviewed, correct := 0, 0
+	for i, item := range items {
+		ex := solver.PlarioExercise(item.Exercise)
+		right := item.CorrectIDs()[0]
+
+		color.New(color.FgYellow).Printf("\ncard %d/%d", i+1, len(items))
+		switch {
+		case item.Card.New():
+			fmt.Print("  new")
+		case item.Card.Lapses > 0:
+			fmt.Printf("  forgotten %d times", item.Card.Lapses)
+		}
+		if item.Wrong > 0 {
+			fmt.Printf("  answered wrong %d times", item.Wrong)
+		}
+		fmt.Println()
+
+		chosen, err := askOption(ex)
+		if errors.Is(err, errQuit) || errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		showResult(ex, chosen, right, item.Explanation)
+		quality := review.Again
+		if chosen == right {
+			correct++
+			if quality, err = askQuality(); err != nil {
+				return err
+			}
+		} else {
+			readLine("[enter] next question ")
+		}
+		reviewed++
+
+		now := time.Now()
+		if err := db.SaveCard(ctx, item.Card.Next(quality, now)); err != nil {
+			return err
+		}
+		err = db.CreateAttempt(ctx, storage.Attempt{
+			ExerciseID: item.ID,
+			Model:      storage.LearnerModel,
+			ChosenIDs:  []int{chosen},
+			CorrectIDs: []int{right},
+			Correct:    chosen == right,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	fmt.Printf("reviewed %d, correct %d\n", reviewed, correct)
+	return printNextDue(ctx, db)
+}
+
+// askOption reads the answer to exercise, cmdQuit quits the session
+func askOption(ex *pl.Exercise) (int, error) {
+	printExercise(ex, 0)
+	for {
+		line, err := readLine("<letter> answer, " + cmdQuit + " quit: ")
+		if err != nil {
+			return 0, err
+		}
+		if strings.ToLower(line) == cmdQuit {
+			return 0, errQuit
+		}
+		if id, ok := parseOption(ex, line); ok {
+			return id, nil
+		}
+		fmt.Println("no such option")
+	}
+}
+
+// askQuality lets the learner say how hard a right answer was, good by default
+func askQuality() (int, error) {
+	for {
+		line, err := readLine("[enter] good, h hard, e easy: ")
+		if err != nil {
+			return 0, err
+		}
+		switch strings.ToLower(line) {
+		case "":
+			return review.Good, nil
+		case "h":
+			return review.Hard, nil
+		case "e":
+			return review.Easy, nil
+		}
+	}
+}
+
+func printNextDue(ctx context.Context, db *database.DB) error {
+	due, err := db.NextDue(ctx)
+	if err != nil || due.IsZero() {
+		return err
+	}
+	fmt.Printf("next card is due %s\n", due.Local().Format("2006-01-02 15:04"))
+	return nil
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"os"
+	"pkg/database"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/rodaine/table"
+)
+
+// list past runs, or mastery timeline as csv for charting
+func RunsCommand(args []string) error {
+	fs := newFlagSet("runs")
+	dbPath := fs.String("db", "", "required: path to sqlite question bank")
+	module := fs.Int("module", 0, "optional: only runs of module_id")
+	mastery := fs.Bool("mastery", false, "optional: print mastery readings as csv instead of runs")
+	since := fs.Duration("since", 0, "optional: only mastery readings newer than this, e.g. 336h for two weeks")
+	fs.Parse(args)
+
+	if *dbPath == "" {
+		fs.Usage()
+		return fmt.Errorf("-db is required")
+	}
+
+	ctx := context.Background()
+	db, err := database.New(ctx, *dbPath)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	if *mastery {
+		var from time.Time
+		if *since > 0 {
+			from = time.Now().Add(-*since)
+		}
+		samples, err := db.ListMasterySamples(ctx, *module, from)
+		if err != nil {
+			return err
+		}
+
+		w := csv.NewWriter(os.Stdout)
+		w.Write([]string{"time", "module_id", "run_id", "mastery"})
+		for _, s := range samples {
+			w.Write([]string{s.CreatedAt.Local().Format(time.RFC3339), strconv.Itoa(s.ModuleID), strconv.FormatInt(s.RunID, 10), strconv.FormatFloat(s.Mastery, 'f', 4, 64)})
+		}
+		w.Flush()
+		return w.Error()
+	}
+
+	runs, err := db.ListRuns(ctx, *module)
+	if err != nil {
+		return err
+	}
+
+	headerFmt := color.New(color.FgWhite, color.Underline, color.Bold, color.Underline).SprintfFunc()
+	columnFmt := color.New(color.FgYellow).SprintfFunc()
+
+	t := table.New("id", "started", "duration", "m_id", "model", "correct", "wrong", "skipped", "bank", "exit", "flags")
+	t.WithHeaderFormatter(headerFmt).WithFirstColumnFormatter(columnFmt)
+
+	for _, r := range runs {
+		duration := "-"
+		if !r.EndedAt.IsZero() {
+			duration = r.EndedAt.Sub(r.StartedAt).Round(time.Second).String()
+		}
+		t.AddRow(r.ID, r.StartedAt.Local().Format("2006-01-02 15:04"), duration, r.ModuleID, r.Model, r.Correct, r.Wrong, r.Skipped,
+			fmt.Sprintf("%d/%d", r.BankHits, r.BankHits+r.BankMisses), r.ExitReason, formatFlags(r.Flags))
+	}
+	t.Print()
+	return nil
+}
+
+func formatFlags(flags map[string]string) string {
+	parts := make([]string, 0, len(flags))
+	for k, v := range flags {
+		parts = append(parts, fmt.Sprintf("%s=%s", k, v))
+	}
+	sort.Strings(parts)
+	return strings.Join(parts, " ")
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	pl "pkg/plario"
+	"pkg/prompt"
+	"pkg/solver"
+	"strings"
+
+	"github.com/fatih/color"
+)
+
+// Study shows the exercise and reads the learner's answer, hints are asked
+// from the model on request and get more detailed each time
+func Study(client *http.Client, solve *solver.Solver, d prompt.Data, ex *pl.Exercise) (int, error) {
+	printExercise(ex, 0)
+
+	var hints []string
+	for {
+		ask := "<letter> answer, " + cmdHint + " hint, " + cmdQuit + " quit: "
+		if len(hints) == solver.MaxHintLevel {
+			ask = "<letter> answer, " + cmdQuit + " quit: "
+		}
+		line, err := readLine(ask)
+		if err != nil {
+			return 0, err
+		}
+
+		switch strings.ToLower(line) {
+		case cmdQuit:
+			return 0, errQuit
+		case cmdHint:
+			if len(hints) == solver.MaxHintLevel {
+				fmt.Println("no more hints")
+				continue
+			}
+			hint, err := solve.Hint(client, d, ex, len(hints)+1, hints)
+			if err != nil {
+				fmt.Printf("hint is not available: %s\n", err)
+				continue
+			}
+			hints = append(hints, hint)
+			color.New(color.FgYellow).Printf("hint %d/%d: ", len(hints), solver.MaxHintLevel)
+			fmt.Printf("%s\n\n", pl.LatexToUnicode(hint))
+			continue
+		}
+
+		if id, ok := parseOption(ex, line); ok {
+			return id, nil
+		}
+		fmt.Println("no such option")
+	}
+}
+
+// Review tells the learner how the answer went and shows the explanation
+func Review(ex *pl.Exercise, chosen, right int, explanation string) {
+	showResult(ex, chosen, right, explanation)
+	readLine("[enter] next question ")
+}
+
+func showResult(ex *pl.Exercise, chosen, right int, explanation string) {
+	if chosen == right {
+		color.New(color.FgGreen, color.Bold).Printf("\ncorrect: %s\n", labelOf(ex, right))
+	} else {
+		color.New(color.FgRed, color.Bold).Printf("\nwrong: %s, correct: %s\n", labelOf(ex, chosen), labelOf(ex, right))
+	}
+	if explanation != "" {
+		fmt.Printf("%s\n", pl.LatexToUnicode(explanation))
+	}
+	fmt.Println()
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"pkg/database"
+	pl "pkg/plario"
+	"pkg/storage"
+)
+
+// Sync stores subjects, courses and modules available to the learner,
+// subjects are fetched when nil. Modules are fetched per course, plario
+// CourseID is restored afterwards.
+func Sync(ctx context.Context, plario *pl.Plario, client *http.Client, store storage.Store, subjects []pl.Subject) (storage.SyncResult, error) {
+	if subjects == nil {
+		var err error
+		subjects, err = plario.GetAvailable(client)
+		if err != nil {
+			return storage.SyncResult{}, err
+		}
+	}
+
+	courseID := plario.CourseID
+	defer func() { plario.CourseID = courseID }()
+
+	var c storage.Catalog
+	for _, s := range subjects {
+		c.Subjects = append(c.Subjects, storage.CatalogEntry{ID: s.ID, Name: s.Name})
+		for _, course := range s.Courses {
+			c.Courses = append(c.Courses, storage.CatalogEntry{ID: course.ID, Name: course.Name, ParentID: s.ID})
+
+			plario.CourseID = course.ID
+			modules, err := plario.GetModules(client)
+			if err != nil {
+				return storage.SyncResult{}, fmt.Errorf("modules of course %d: %s", course.ID, err)
+			}
+			for _, m := range modules {
+				c.Modules = append(c.Modules, storage.CatalogEntry{ID: m.ID, Name: m.Name, ParentID: course.ID})
+			}
+		}
+	}
+
+	return store.SyncCatalog(ctx, c)
+}
+
+// store catalog of the platform in the question bank
+func SyncCommand(args []string) error {
+	fs := newFlagSet("sync")
+	token := fs.String("ptoken", "", "required: plario access token")
+	dbPath := fs.String("db", "", "required: path to sqlite question bank")
+	fs.Parse(args)
+
+	if *token == "" || *dbPath == "" {
+		fs.Usage()
+		return fmt.Errorf("-ptoken and -db are required")
+	}
+
+	ctx := context.Background()
+	db, err := database.New(ctx, *dbPath)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	logger := InitLogger("error")
+	res, err := Sync(ctx, pl.NewPlario(*token, logger), &http.Client{}, db, nil)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("added %d, renamed %d, unchanged %d\n", res.Added, res.Renamed, res.Unchanged)
+	return nil
+}
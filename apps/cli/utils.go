@@ -1,12 +1,19 @@
 package main
 
 import (
+	"context"
+	"flag"
 	"fmt"
 	"log"
 	"log/slog"
 	"math/rand"
 	"net/http"
+	"os"
+	"pkg/llm"
 	pl "pkg/plario"
+	"pkg/prompt"
+	"pkg/storage"
+	"strings"
 
 	"github.com/fatih/color"
 	"github.com/rodaine/table"
@@ -34,6 +41,102 @@ func QuizMode(plario *pl.Plario, client *http.Client) error {
 	return nil
 }
 
+func usage() {
+	fmt.Fprintf(flag.CommandLine.Output(), "Usage of %s:\n", os.Args[0])
+	flag.PrintDefaults()
+	fmt.Fprintf(flag.CommandLine.Output(), "\nCommands: %s\n", strings.Join(commandNames(), ", "))
+}
+
+// collect subject, course and module names for prompt templates
+func PromptData(plario *pl.Plario, subjects []pl.Subject, modules []pl.Module, logger *slog.Logger) prompt.Data {
+	d := prompt.Data{
+		Subject: prompt.Named{ID: plario.SubjectID},
+		Course:  prompt.Named{ID: plario.CourseID},
+		Module:  prompt.Named{ID: plario.ModuleID},
+		Culture: plario.Culture,
+	}
+
+	for _, s := range subjects {
+		if s.ID == plario.SubjectID {
+			d.Subject.Name = s.Name
+		}
+		for _, c := range s.Courses {
+			if c.ID == plario.CourseID {
+				d.Course.Name = c.Name
+			}
+		}
+	}
+	for _, m := range modules {
+		if m.ID == plario.ModuleID {
+			d.Module.Name = m.Name
+		}
+	}
+
+	if d.Subject.Name == "" {
+		logger.Warn("subject not found among available", "subject_id", plario.SubjectID)
+	}
+	if d.Course.Name == "" {
+		logger.Warn("course not found among available", "course_id", plario.CourseID)
+	}
+	if d.Module.Name == "" {
+		logger.Warn("module not found among available", "module_id", plario.ModuleID)
+	}
+	return d
+}
+
+// RegisterCatalog stores subject, course and module of the run, questions
+// of the bank reference them
+func RegisterCatalog(ctx context.Context, store storage.Store, d prompt.Data) error {
+	_, err := store.SyncCatalog(ctx, storage.Catalog{
+		Subjects: []storage.CatalogEntry{{ID: d.Subject.ID, Name: d.Subject.Name}},
+		Courses:  []storage.CatalogEntry{{ID: d.Course.ID, Name: d.Course.Name, ParentID: d.Subject.ID}},
+		Modules:  []storage.CatalogEntry{{ID: d.Module.ID, Name: d.Module.Name, ParentID: d.Course.ID}},
+	})
+	return err
+}
+
+// flags holding secrets are not stored with runs
+var secretFlags = map[string]bool{"ptoken": true, "gtoken": true, "embed_token": true}
+
+// RunFlags returns flags set on the command line without secrets
+func RunFlags() map[string]string {
+	flags := make(map[string]string)
+	flag.Visit(func(f *flag.Flag) {
+		if !secretFlags[f.Name] {
+			flags[f.Name] = f.Value.String()
+		}
+	})
+	return flags
+}
+
+// groq client with run wide settings, include_reasoning is rejected by models
+// without separate reasoning output so it is only set for capable ones
+func NewGroq(m llm.Model, catalog *llm.Catalog, logger *slog.Logger) *llm.Groq {
+	g := llm.NewGroq(groqToken, m, "", logger)
+	if temperature >= 0 {
+		t := temperature
+		g.Temperature = &t
+	}
+	if reasoning {
+		g.Reasoning = true
+		if catalog != nil {
+			info, ok := catalog.Lookup(m)
+			g.Reasoning = ok && info.Capabilities.Reasoning
+		}
+	}
+	return g
+}
+
+func ParseModels(s string) []llm.Model {
+	var models []llm.Model
+	for _, m := range strings.Split(s, ",") {
+		if m = strings.TrimSpace(m); m != "" {
+			models = append(models, llm.Model(m))
+		}
+	}
+	return models
+}
+
 func InitLogger(level string) *slog.Logger {
 	var l slog.Level
 
@@ -48,6 +151,24 @@ func InitLogger(level string) *slog.Logger {
 	return slog.New(handler)
 }
 
+// skipTracker counts exercises left unanswered. plario has no way to move
+// past an exercise, it is served again until answered, so the run stops once
+// one is skipped max times.
+type skipTracker struct {
+	max   int
+	count map[int]int
+}
+
+func newSkipTracker(max int) *skipTracker {
+	return &skipTracker{max: max, count: make(map[int]int)}
+}
+
+// skip records exercise id left unanswered and reports whether the run must stop
+func (s *skipTracker) skip(id int) bool {
+	s.count[id]++
+	return s.count[id] >= s.max
+}
+
 func RandInRange(r *rand.Rand, min, max int) int {
 	return r.Intn(max-min+1) + min
 }
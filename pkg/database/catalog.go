@@ -0,0 +1,93 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"pkg/storage"
+)
+
+// SyncCatalog upserts subjects, courses and modules in one transaction,
+// entries missing from c are kept, the bank may still reference them
+func (db *DB) SyncCatalog(ctx context.Context, c storage.Catalog) (storage.SyncResult, error) {
+	var res storage.SyncResult
+
+	tx, err := db.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return res, fmt.Errorf("SyncCatalog: %s", err)
+	}
+	defer tx.Rollback()
+
+	tables := []struct {
+		name, parent string
+		entries      []storage.CatalogEntry
+	}{
+		{"subjects", "", c.Subjects},
+		{"courses", "subject_id", c.Courses},
+		{"modules", "course_id", c.Modules},
+	}
+	for _, t := range tables {
+		for _, e := range t.entries {
+			var name sql.NullString
+			err := tx.QueryRowContext(ctx, `select name from `+t.name+` where id = ?`, e.ID).Scan(&name)
+			switch {
+			case err == sql.ErrNoRows:
+				res.Added++
+			case err != nil:
+				return res, fmt.Errorf("SyncCatalog: %s", err)
+			case name.String != e.Name && e.Name != "":
+				res.Renamed++
+			default:
+				res.Unchanged++
+			}
+
+			if t.parent == "" {
+				_, err = tx.ExecContext(ctx, `insert into subjects (id, name) values (?, ?)
+					on conflict (id) do update set name = coalesce(nullif(excluded.name, ''), name)`, e.ID, e.Name)
+			} else {
+				_, err = tx.ExecContext(ctx, `insert into `+t.name+` (id, name, `+t.parent+`) values (?, ?, ?)
+					on conflict (id) do update set name = coalesce(nullif(excluded.name, ''), name), `+t.parent+` = excluded.`+t.parent, e.ID, e.Name, e.ParentID)
+			}
+			if err != nil {
+				return res, fmt.Errorf("SyncCatalog: %s", err)
+			}
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return res, fmt.Errorf("SyncCatalog: %s", err)
+	}
+	return res, nil
+}
+
+// Catalog returns stored subjects, courses and modules
+func (db *DB) Catalog(ctx context.Context) (storage.Catalog, error) {
+	var c storage.Catalog
+	tables := []struct {
+		query   string
+		entries *[]storage.CatalogEntry
+	}{
+		{`select id, coalesce(name, ''), 0 from subjects`, &c.Subjects},
+		{`select id, coalesce(name, ''), coalesce(subject_id, 0) from courses`, &c.Courses},
+		{`select id, coalesce(name, ''), coalesce(course_id, 0) from modules`, &c.Modules},
+	}
+	for _, t := range tables {
+		rows, err := db.conn.QueryContext(ctx, t.query+` order by id`)
+		if err != nil {
+			return c, fmt.Errorf("Catalog: %s", err)
+		}
+		for rows.Next() {
+			var e storage.CatalogEntry
+			if err := rows.Scan(&e.ID, &e.Name, &e.ParentID); err != nil {
+				rows.Close()
+				return c, fmt.Errorf("Catalog: %s", err)
+			}
+			*t.entries = append(*t.entries, e)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return c, fmt.Errorf("Catalog: %s", err)
+		}
+	}
+	return c, nil
+}
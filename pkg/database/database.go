@@ -3,17 +3,51 @@ package database
 import (
 	"context"
 	"database/sql"
+	"encoding/binary"
 	"fmt"
+	"math"
+	"pkg/storage"
 	"time"
 
 	_ "github.com/mattn/go-sqlite3"
 )
 
 type DB struct {
-	*sql.DB
+	conn *sql.DB
+
+	// fts is set when bank_search index is available, see ensureSearch
+	fts bool
 }
 
+var _ storage.Store = (*DB)(nil)
+
+// New opens database, applies pending migrations and refreshes outdated
+// fingerprints
 func New(ctx context.Context, dsn string) (*DB, error) {
+	db, err := Open(ctx, dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := db.Migrate(); err != nil {
+		db.Close()
+		return nil, err
+	}
+	if err := db.ensureSearch(ctx); err != nil {
+		db.Close()
+		return nil, err
+	}
+	if err := db.refreshFingerprints(ctx); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return db, nil
+}
+
+// Open opens database without migrating it, a database with schema newer
+// than the binary is refused with ErrSchemaTooNew
+func Open(ctx context.Context, dsn string) (*DB, error) {
 	db, err := sql.Open("sqlite3", dsn)
 	if err != nil {
 		return nil, err
@@ -37,106 +71,246 @@ func New(ctx context.Context, dsn string) (*DB, error) {
 		return nil, err
 	}
 
-	stmts := []string{
-		`create table if not exists subjects (id integer primary key, name text)`,
-		`create table if not exists courses (
-			id integer primary key,
-			name text,
+	d := &DB{conn: db}
+	if err := d.checkVersion(); err != nil {
+		_ = db.Close()
+		return nil, err
+	}
 
-			subject_id integer references subjects(id)
-		)`,
-		`create table if not exists modules (
-			id integer primary key,
-			name text,
+	return d, nil
+}
 
-			course_id integer references courses(id)
-		)`,
-		`create table if not exists components (
-			id integer primary key,
-			name text,
+// Close closes the database
+func (db *DB) Close() error {
+	return db.conn.Close()
+}
 
-			module_id integer references modules(id)
-		)`,
-		`create table if not exists questions (
-			id integer,
-			content text,
-			right_answer integer,
+// CreateTheoryChunks stores chunks over ones at the same positions, used to
+// update embeddings of already indexed chunks
+func (db *DB) CreateTheoryChunks(ctx context.Context, chunks []storage.TheoryChunk) error {
+	tx, err := db.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("CreateTheoryChunks: %s", err)
+	}
+	defer tx.Rollback()
 
-			subject_id integer references subjects(id),
-			course_id integer references courses(id),
-			module_id integer references modules(id)
-		)`,
+	if err := insertTheoryChunks(ctx, tx, chunks); err != nil {
+		return fmt.Errorf("CreateTheoryChunks: %s", err)
 	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("CreateTheoryChunks: %s", err)
+	}
+	return nil
+}
 
-	tx, err := db.Begin()
+// ReplaceTheoryChunks stores chunks of a lesson instead of all its previous
+// ones, a shorter lesson leaves no stale chunks behind
+func (db *DB) ReplaceTheoryChunks(ctx context.Context, activityID int, chunks []storage.TheoryChunk) error {
+	tx, err := db.conn.BeginTx(ctx, nil)
 	if err != nil {
-		db.Close()
-		return nil, err
+		return fmt.Errorf("ReplaceTheoryChunks: %s", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `delete from theory_chunks where activity_id = ?`, activityID); err != nil {
+		return fmt.Errorf("ReplaceTheoryChunks: %s", err)
 	}
+	if err := insertTheoryChunks(ctx, tx, chunks); err != nil {
+		return fmt.Errorf("ReplaceTheoryChunks: %s", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("ReplaceTheoryChunks: %s", err)
+	}
+	return nil
+}
 
-	for _, s := range stmts {
-		if _, err := tx.Exec(s); err != nil {
-			tx.Rollback()
-			db.Close()
-			return nil, err
+func insertTheoryChunks(ctx context.Context, tx *sql.Tx, chunks []storage.TheoryChunk) error {
+	query := `insert or replace into theory_chunks (activity_id, position, content, embedding, embedding_model, course_id, module_id) values (?, ?, ?, ?, ?, ?, ?)`
+	for _, c := range chunks {
+		if _, err := tx.ExecContext(ctx, query, c.ActivityID, c.Position, c.Content, encodeVector(c.Embedding), c.EmbeddingModel, c.CourseID, c.ModuleID); err != nil {
+			return err
 		}
 	}
+	return nil
+}
 
-	if err := tx.Commit(); err != nil {
-		db.Close()
-		return nil, err
+// ListTheoryChunks returns chunks of module ordered by lesson and position
+func (db *DB) ListTheoryChunks(ctx context.Context, moduleID int) ([]storage.TheoryChunk, error) {
+	query := `select activity_id, position, content, embedding, coalesce(embedding_model, ''), course_id, module_id
+		from theory_chunks where module_id = ? order by activity_id, position`
+
+	rows, err := db.conn.QueryContext(ctx, query, moduleID)
+	if err != nil {
+		return nil, fmt.Errorf("ListTheoryChunks: %s", err)
+	}
+	defer rows.Close()
+
+	var chunks []storage.TheoryChunk
+	for rows.Next() {
+		var c storage.TheoryChunk
+		var embedding []byte
+		if err := rows.Scan(&c.ActivityID, &c.Position, &c.Content, &embedding, &c.EmbeddingModel, &c.CourseID, &c.ModuleID); err != nil {
+			return nil, fmt.Errorf("ListTheoryChunks: %s", err)
+		}
+		c.Embedding = decodeVector(embedding)
+		chunks = append(chunks, c)
 	}
 
-	return &DB{db}, nil
+	return chunks, rows.Err()
+}
+
+// vectors are stored as little endian float32 blobs
+func encodeVector(v []float32) []byte {
+	if len(v) == 0 {
+		return nil
+	}
+	b := make([]byte, 4*len(v))
+	for i, f := range v {
+		binary.LittleEndian.PutUint32(b[4*i:], math.Float32bits(f))
+	}
+	return b
+}
+
+func decodeVector(b []byte) []float32 {
+	if len(b) == 0 {
+		return nil
+	}
+	v := make([]float32, len(b)/4)
+	for i := range v {
+		v[i] = math.Float32frombits(binary.LittleEndian.Uint32(b[4*i:]))
+	}
+	return v
 }
 
-func (db *DB) CreateSubject(subjectID int, subjectName string) error {
-	query := `insert or ignore into subjects (id, name) values (?, ?)`
-	if _, err := db.Exec(query, subjectID, subjectName); err != nil {
-		return fmt.Errorf("CreateSubject: %s", err)
+func (db *DB) CreateReasoning(ctx context.Context, r storage.Reasoning) error {
+	query := `insert into reasonings (question_id, model, reasoning, answer, course_id, module_id) values (?, ?, ?, ?, ?, ?)`
+	if _, err := db.conn.ExecContext(ctx, query, r.QuestionID, r.Model, r.Reasoning, r.Answer, r.CourseID, r.ModuleID); err != nil {
+		return fmt.Errorf("CreateReasoning: %s", err)
 	}
 
 	return nil
 }
 
-func (db *DB) CreateCourse(courseID int, courseName string, subjectID int) error {
-	query := `insert or ignore into courses (id, name, subject_id) values (?, ?, ?)`
-	if _, err := db.Exec(query, courseID, courseName, subjectID); err != nil {
-		return fmt.Errorf("CreateCourse: %s", err)
+// ListReasonings returns stored reasonings for a question, newest first
+func (db *DB) ListReasonings(ctx context.Context, questionID, moduleID int) ([]storage.Reasoning, error) {
+	query := `select question_id, model, reasoning, answer, created_at, course_id, module_id from reasonings
+		where question_id = ? and module_id = ? order by created_at desc, id desc`
+
+	rows, err := db.conn.QueryContext(ctx, query, questionID, moduleID)
+	if err != nil {
+		return nil, fmt.Errorf("ListReasonings: %s", err)
+	}
+	defer rows.Close()
+
+	var reasonings []storage.Reasoning
+	for rows.Next() {
+		var r storage.Reasoning
+		if err := rows.Scan(&r.QuestionID, &r.Model, &r.Reasoning, &r.Answer, &r.CreatedAt, &r.CourseID, &r.ModuleID); err != nil {
+			return nil, fmt.Errorf("ListReasonings: %s", err)
+		}
+		reasonings = append(reasonings, r)
+	}
+
+	return reasonings, rows.Err()
+}
+
+// GetCachedAnswer returns nil when key is missing or expired
+func (db *DB) GetCachedAnswer(ctx context.Context, key string) (*storage.CachedAnswer, error) {
+	query := `select key, model, answer, content, confidence, expires_at from solver_cache where key = ? and expires_at > ?`
+
+	var c storage.CachedAnswer
+	err := db.conn.QueryRowContext(ctx, query, key, time.Now().UTC()).Scan(&c.Key, &c.Model, &c.Answer, &c.Content, &c.Confidence, &c.ExpiresAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("GetCachedAnswer: %s", err)
+	}
+
+	return &c, nil
+}
+
+// PutCachedAnswer stores c over the answer with the same key and drops
+// expired ones
+func (db *DB) PutCachedAnswer(ctx context.Context, c storage.CachedAnswer) error {
+	query := `insert or replace into solver_cache (key, model, answer, content, confidence, expires_at) values (?, ?, ?, ?, ?, ?)`
+	if _, err := db.conn.ExecContext(ctx, query, c.Key, c.Model, c.Answer, c.Content, c.Confidence, c.ExpiresAt.UTC()); err != nil {
+		return fmt.Errorf("PutCachedAnswer: %s", err)
+	}
+
+	if _, err := db.conn.ExecContext(ctx, `delete from solver_cache where expires_at <= ?`, time.Now().UTC()); err != nil {
+		return fmt.Errorf("PutCachedAnswer: %s", err)
 	}
 
 	return nil
 }
 
-func (db *DB) CreateModule(moduleID int, moduleName string, courseID int) error {
-	query := `insert or ignore into modules (id, name, course_id) values (?, ?, ?)`
-	if _, err := db.Exec(query, moduleID, moduleName, courseID); err != nil {
-		return fmt.Errorf("CreateModule: %s", err)
+func (db *DB) CreateDecision(ctx context.Context, d storage.Decision) error {
+	query := `insert into decisions (question_id, model, model_answer, human_answer, action, course_id, module_id) values (?, ?, ?, ?, ?, ?, ?)`
+	if _, err := db.conn.ExecContext(ctx, query, d.QuestionID, d.Model, d.ModelAnswer, d.HumanAnswer, d.Action, d.CourseID, d.ModuleID); err != nil {
+		return fmt.Errorf("CreateDecision: %s", err)
 	}
 
 	return nil
 }
 
-func (db *DB) CreateQuestion(questionID int, questionContent string, rightAnswer int, subjectID, courseID, moduleID int) error {
-	query := `insert or ignore into questions (id, content, right_answer, subject_id, course_id, module_id) values (?, ?, ?, ?, ?, ?)`
-	if _, err := db.Exec(query, questionID, questionContent, rightAnswer, subjectID, courseID, moduleID); err != nil {
-		return fmt.Errorf("CreateQuestion: %s", err)
+// ListDecisions returns decisions of module (all modules when 0), newest
+// first, disagreed keeps only changed and skipped ones
+func (db *DB) ListDecisions(ctx context.Context, moduleID int, disagreed bool) ([]storage.Decision, error) {
+	query := `select question_id, model, model_answer, human_answer, action, created_at, course_id, module_id from decisions
+		where (? = 0 or module_id = ?) and (? = 0 or action != ?)
+		order by created_at desc, id desc`
+
+	rows, err := db.conn.QueryContext(ctx, query, moduleID, moduleID, disagreed, storage.ActionAccepted)
+	if err != nil {
+		return nil, fmt.Errorf("ListDecisions: %s", err)
+	}
+	defer rows.Close()
+
+	var decisions []storage.Decision
+	for rows.Next() {
+		var d storage.Decision
+		if err := rows.Scan(&d.QuestionID, &d.Model, &d.ModelAnswer, &d.HumanAnswer, &d.Action, &d.CreatedAt, &d.CourseID, &d.ModuleID); err != nil {
+			return nil, fmt.Errorf("ListDecisions: %s", err)
+		}
+		decisions = append(decisions, d)
+	}
+
+	return decisions, rows.Err()
+}
+
+func (db *DB) CreateMistake(ctx context.Context, m storage.Mistake) error {
+	query := `insert into mistakes (question_id, question, chosen_answer, chosen_text, right_answer, right_text, explanation, model, course_id, module_id)
+		values (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	if _, err := db.conn.ExecContext(ctx, query, m.QuestionID, m.Question, m.ChosenAnswer, m.ChosenText, m.RightAnswer, m.RightText, m.Explanation, m.Model, m.CourseID, m.ModuleID); err != nil {
+		return fmt.Errorf("CreateMistake: %s", err)
 	}
 
 	return nil
 }
 
-func (db *DB) GetAnswer(questionID, subjectID, courseID, moduleID int) (int, error) {
-	query := `select right_answer from questions where id = ? and subject_id = ? and course_id = ? and module_id = ?`
+// ListMistakes returns mistakes of module (all modules when 0) ordered by
+// module and time they were made
+func (db *DB) ListMistakes(ctx context.Context, moduleID int) ([]storage.Mistake, error) {
+	query := `select question_id, question, chosen_answer, chosen_text, right_answer, right_text, explanation, model, created_at, course_id, module_id,
+		coalesce((select name from modules where modules.id = mistakes.module_id), '')
+		from mistakes where (? = 0 or module_id = ?)
+		order by course_id, module_id, created_at, id`
 
-	var answer int
-	err := db.QueryRow(query, questionID, subjectID, courseID, moduleID).Scan(&answer)
+	rows, err := db.conn.QueryContext(ctx, query, moduleID, moduleID)
 	if err != nil {
-		if err == sql.ErrNoRows {
-			return 0, nil
+		return nil, fmt.Errorf("ListMistakes: %s", err)
+	}
+	defer rows.Close()
+
+	var mistakes []storage.Mistake
+	for rows.Next() {
+		var m storage.Mistake
+		if err := rows.Scan(&m.QuestionID, &m.Question, &m.ChosenAnswer, &m.ChosenText, &m.RightAnswer, &m.RightText, &m.Explanation, &m.Model, &m.CreatedAt, &m.CourseID, &m.ModuleID, &m.ModuleName); err != nil {
+			return nil, fmt.Errorf("ListMistakes: %s", err)
 		}
-		return 0, err
+		mistakes = append(mistakes, m)
 	}
 
-	return answer, nil
+	return mistakes, rows.Err()
 }
@@ -0,0 +1,230 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"pkg/storage"
+	"pkg/textsim"
+)
+
+// SaveExercise stores exercise with its options, known correctness of
+// options is kept when the exercise is saved again
+func (db *DB) SaveExercise(ctx context.Context, e storage.Exercise) error {
+	tx, err := db.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("SaveExercise: %s", err)
+	}
+	defer tx.Rollback()
+
+	query := `insert into exercises (id, kind, content, fingerprint, fingerprint_version, question_text, answers_text, subject_id, course_id, module_id)
+		values (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		on conflict (id) do update set kind = excluded.kind, content = excluded.content, fingerprint = excluded.fingerprint,
+			fingerprint_version = excluded.fingerprint_version, question_text = excluded.question_text, answers_text = excluded.answers_text,
+			subject_id = excluded.subject_id, course_id = excluded.course_id, module_id = excluded.module_id, updated_at = current_timestamp`
+	if _, err := tx.ExecContext(ctx, query, e.ID, e.Kind, e.Content, e.Fingerprint, textsim.FingerprintVersion, e.QuestionText, e.AnswersText, e.SubjectID, e.CourseID, e.ModuleID); err != nil {
+		return fmt.Errorf("SaveExercise: %s", err)
+	}
+
+	query = `insert into answer_options (exercise_id, answer_id, position, text, correct) values (?, ?, ?, ?, ?)
+		on conflict (exercise_id, answer_id) do update set position = excluded.position, text = excluded.text,
+			correct = coalesce(excluded.correct, answer_options.correct)`
+	for i, o := range e.Options {
+		if _, err := tx.ExecContext(ctx, query, e.ID, o.ID, i, o.Text, o.Correct); err != nil {
+			return fmt.Errorf("SaveExercise: %s", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("SaveExercise: %s", err)
+	}
+	return nil
+}
+
+// SetCorrect marks correctIDs as right options of exercise and the rest as wrong
+func (db *DB) SetCorrect(ctx context.Context, exerciseID int, correctIDs []int) error {
+	ids, err := json.Marshal(correctIDs)
+	if err != nil {
+		return fmt.Errorf("SetCorrect: %s", err)
+	}
+
+	query := `update answer_options set correct = answer_id in (select value from json_each(?)) where exercise_id = ?`
+	if _, err := db.conn.ExecContext(ctx, query, string(ids), exerciseID); err != nil {
+		return fmt.Errorf("SetCorrect: %s", err)
+	}
+	return nil
+}
+
+const exerciseColumns = `id, kind, content, coalesce(fingerprint, ''), coalesce(question_text, ''), coalesce(answers_text, ''), subject_id, course_id, module_id, created_at, updated_at`
+
+// GetExercise returns exercise with options in platform order, nil if it was
+// never seen
+func (db *DB) GetExercise(ctx context.Context, id int) (*storage.Exercise, error) {
+	exercises, err := db.queryExercises(ctx, `where id = ?`, id)
+	if err != nil {
+		return nil, fmt.Errorf("GetExercise: %s", err)
+	}
+	if len(exercises) == 0 {
+		return nil, nil
+	}
+	return &exercises[0], nil
+}
+
+// FindExercises returns exercises with the content fingerprint, the same
+// question may come under different ids in other courses
+func (db *DB) FindExercises(ctx context.Context, fingerprint string) ([]storage.Exercise, error) {
+	exercises, err := db.queryExercises(ctx, `where fingerprint = ? order by updated_at desc`, fingerprint)
+	if err != nil {
+		return nil, fmt.Errorf("FindExercises: %s", err)
+	}
+	return exercises, nil
+}
+
+// ListExercises returns exercises of subject (all subjects when 0) that
+// have a known right option
+func (db *DB) ListExercises(ctx context.Context, subjectID int) ([]storage.Exercise, error) {
+	exercises, err := db.queryExercises(ctx, `where (? = 0 or subject_id = ?)
+		and exists (select 1 from answer_options o where o.exercise_id = exercises.id and o.correct = 1)
+		order by id`, subjectID, subjectID)
+	if err != nil {
+		return nil, fmt.Errorf("ListExercises: %s", err)
+	}
+	return exercises, nil
+}
+
+// AllExercises returns every stored exercise ordered by id
+func (db *DB) AllExercises(ctx context.Context) ([]storage.Exercise, error) {
+	exercises, err := db.queryExercises(ctx, `order by id`)
+	if err != nil {
+		return nil, fmt.Errorf("AllExercises: %s", err)
+	}
+	return exercises, nil
+}
+
+// queryExercises selects exercises by where clause and fills their options
+func (db *DB) queryExercises(ctx context.Context, where string, args ...any) ([]storage.Exercise, error) {
+	rows, err := db.conn.QueryContext(ctx, `select `+exerciseColumns+` from exercises `+where, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	var exercises []storage.Exercise
+	index := make(map[int]int)
+	for rows.Next() {
+		var e storage.Exercise
+		if err := rows.Scan(&e.ID, &e.Kind, &e.Content, &e.Fingerprint, &e.QuestionText, &e.AnswersText, &e.SubjectID, &e.CourseID, &e.ModuleID, &e.CreatedAt, &e.UpdatedAt); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		index[e.ID] = len(exercises)
+		exercises = append(exercises, e)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(exercises) == 0 {
+		return nil, nil
+	}
+
+	ids := make([]int, 0, len(exercises))
+	for _, e := range exercises {
+		ids = append(ids, e.ID)
+	}
+	filter, err := json.Marshal(ids)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err = db.conn.QueryContext(ctx, `select exercise_id, answer_id, text, correct from answer_options
+		where exercise_id in (select value from json_each(?)) order by exercise_id, position`, string(filter))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var exerciseID int
+		var o storage.AnswerOption
+		var correct sql.NullBool
+		if err := rows.Scan(&exerciseID, &o.ID, &o.Text, &correct); err != nil {
+			return nil, err
+		}
+		if correct.Valid {
+			o.Correct = &correct.Bool
+		}
+		e := &exercises[index[exerciseID]]
+		e.Options = append(e.Options, o)
+	}
+
+	return exercises, rows.Err()
+}
+
+// CorrectAnswers returns ids of options known to be right, empty when the
+// exercise was never answered
+func (db *DB) CorrectAnswers(ctx context.Context, exerciseID int) ([]int, error) {
+	rows, err := db.conn.QueryContext(ctx, `select answer_id from answer_options where exercise_id = ? and correct = 1 order by position`, exerciseID)
+	if err != nil {
+		return nil, fmt.Errorf("CorrectAnswers: %s", err)
+	}
+	defer rows.Close()
+
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("CorrectAnswers: %s", err)
+		}
+		ids = append(ids, id)
+	}
+
+	return ids, rows.Err()
+}
+
+func (db *DB) CreateAttempt(ctx context.Context, a storage.Attempt) error {
+	chosen, err := json.Marshal(a.ChosenIDs)
+	if err != nil {
+		return fmt.Errorf("CreateAttempt: %s", err)
+	}
+	correct, err := json.Marshal(a.CorrectIDs)
+	if err != nil {
+		return fmt.Errorf("CreateAttempt: %s", err)
+	}
+
+	query := `insert into attempts (exercise_id, session, model, chosen_ids, correct_ids, correct) values (?, ?, ?, ?, ?, ?)`
+	if _, err := db.conn.ExecContext(ctx, query, a.ExerciseID, a.Session, a.Model, string(chosen), string(correct), a.Correct); err != nil {
+		return fmt.Errorf("CreateAttempt: %s", err)
+	}
+	return nil
+}
+
+// ListAttempts returns attempts of exercise (all exercises when 0), oldest first
+func (db *DB) ListAttempts(ctx context.Context, exerciseID int) ([]storage.Attempt, error) {
+	query := `select id, exercise_id, session, model, chosen_ids, correct_ids, correct, created_at from attempts
+		where (? = 0 or exercise_id = ?) order by created_at, id`
+
+	rows, err := db.conn.QueryContext(ctx, query, exerciseID, exerciseID)
+	if err != nil {
+		return nil, fmt.Errorf("ListAttempts: %s", err)
+	}
+	defer rows.Close()
+
+	var attempts []storage.Attempt
+	for rows.Next() {
+		var a storage.Attempt
+		var chosen, correct string
+		if err := rows.Scan(&a.ID, &a.ExerciseID, &a.Session, &a.Model, &chosen, &correct, &a.Correct, &a.CreatedAt); err != nil {
+			return nil, fmt.Errorf("ListAttempts: %s", err)
+		}
+		if err := json.Unmarshal([]byte(chosen), &a.ChosenIDs); err != nil {
+			return nil, fmt.Errorf("ListAttempts: %s", err)
+		}
+		if err := json.Unmarshal([]byte(correct), &a.CorrectIDs); err != nil {
+			return nil, fmt.Errorf("ListAttempts: %s", err)
+		}
+		attempts = append(attempts, a)
+	}
+
+	return attempts, rows.Err()
+}
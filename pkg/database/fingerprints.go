@@ -0,0 +1,50 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	pl "pkg/plario"
+	"pkg/textsim"
+)
+
+// refreshFingerprints computes fingerprints stored by an older version of
+// textsim.Fingerprint again, they would not match current ones
+func (db *DB) refreshFingerprints(ctx context.Context) error {
+	rows, err := db.conn.QueryContext(ctx, `select id, content from exercises where fingerprint_version < ?`, textsim.FingerprintVersion)
+	if err != nil {
+		return fmt.Errorf("refreshFingerprints: %s", err)
+	}
+	stale := make(map[int]string)
+	for rows.Next() {
+		var id int
+		var html string
+		if err := rows.Scan(&id, &html); err != nil {
+			rows.Close()
+			return fmt.Errorf("refreshFingerprints: %s", err)
+		}
+		stale[id] = html
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("refreshFingerprints: %s", err)
+	}
+	if len(stale) == 0 {
+		return nil
+	}
+
+	tx, err := db.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("refreshFingerprints: %s", err)
+	}
+	defer tx.Rollback()
+	for id, html := range stale {
+		query := `update exercises set fingerprint = ?, fingerprint_version = ? where id = ?`
+		if _, err := tx.ExecContext(ctx, query, pl.Fingerprint(html), textsim.FingerprintVersion, id); err != nil {
+			return fmt.Errorf("refreshFingerprints: %s", err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("refreshFingerprints: %s", err)
+	}
+	return nil
+}
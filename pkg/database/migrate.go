@@ -0,0 +1,142 @@
+package database
+
+import (
+	"embed"
+	"errors"
+	"fmt"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+var ErrSchemaTooNew = errors.New("database schema is newer than this binary supports")
+
+// Migration is one embedded migrations/<version>_<name>.sql file, applied
+// version is kept in pragma user_version
+type Migration struct {
+	Version int
+	Name    string
+	SQL     string
+}
+
+// Migrations returns embedded migrations ordered by version
+func Migrations() ([]Migration, error) {
+	entries, err := migrationFiles.ReadDir("migrations")
+	if err != nil {
+		return nil, fmt.Errorf("Migrations: %s", err)
+	}
+
+	var migrations []Migration
+	for _, e := range entries {
+		name := strings.TrimSuffix(e.Name(), ".sql")
+		version, title, ok := strings.Cut(name, "_")
+		if !ok {
+			return nil, fmt.Errorf("Migrations: bad file name %s", e.Name())
+		}
+		v, err := strconv.Atoi(version)
+		if err != nil {
+			return nil, fmt.Errorf("Migrations: bad file name %s", e.Name())
+		}
+
+		b, err := migrationFiles.ReadFile(path.Join("migrations", e.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("Migrations: %s", err)
+		}
+		migrations = append(migrations, Migration{Version: v, Name: title, SQL: string(b)})
+	}
+
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	for i, m := range migrations {
+		if m.Version != i+1 {
+			return nil, fmt.Errorf("Migrations: expected version %d, got %d", i+1, m.Version)
+		}
+	}
+	return migrations, nil
+}
+
+// LatestVersion is the schema version this binary migrates to
+func LatestVersion() int {
+	migrations, err := Migrations()
+	if err != nil {
+		return 0
+	}
+	return len(migrations)
+}
+
+// Version returns schema version of the database
+func (db *DB) Version() (int, error) {
+	var v int
+	if err := db.conn.QueryRow(`pragma user_version`).Scan(&v); err != nil {
+		return 0, fmt.Errorf("Version: %s", err)
+	}
+	return v, nil
+}
+
+func (db *DB) checkVersion() error {
+	v, err := db.Version()
+	if err != nil {
+		return err
+	}
+	if latest := LatestVersion(); v > latest {
+		return fmt.Errorf("%w: version %d, latest known %d", ErrSchemaTooNew, v, latest)
+	}
+	return nil
+}
+
+// Pending returns migrations not yet applied to the database
+func (db *DB) Pending() ([]Migration, error) {
+	v, err := db.Version()
+	if err != nil {
+		return nil, err
+	}
+	migrations, err := Migrations()
+	if err != nil {
+		return nil, err
+	}
+	if v > len(migrations) {
+		return nil, fmt.Errorf("%w: version %d, latest known %d", ErrSchemaTooNew, v, len(migrations))
+	}
+	return migrations[v:], nil
+}
+
+// Migrate applies pending migrations, each in its own transaction together
+// with the version bump, and returns the applied ones
+func (db *DB) Migrate() ([]Migration, error) {
+	pending, err := db.Pending()
+	if err != nil {
+		return nil, err
+	}
+
+	for i, m := range pending {
+		if err := db.apply(m); err != nil {
+			return pending[:i], err
+		}
+	}
+	return pending, nil
+}
+
+func (db *DB) apply(m Migration) error {
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return fmt.Errorf("Migrate %04d_%s: %s", m.Version, m.Name, err)
+	}
+
+	if _, err := tx.Exec(m.SQL); err != nil {
+		tx.Rollback()
+		return fmt.Errorf("Migrate %04d_%s: %s", m.Version, m.Name, err)
+	}
+	// pragma does not take placeholders, version is an int from file name
+	if _, err := tx.Exec(fmt.Sprintf(`pragma user_version = %d`, m.Version)); err != nil {
+		tx.Rollback()
+		return fmt.Errorf("Migrate %04d_%s: %s", m.Version, m.Name, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("Migrate %04d_%s: %s", m.Version, m.Name, err)
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	pl "pkg/plario"
+	"pkg/storage"
+	"testing"
+)
+
+func TestMigrate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bank.db")
+	db, err := New(t.Context(), path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v, err := db.Version(); err != nil || v != LatestVersion() {
+		t.Fatalf("version %d %v, want %d", v, err, LatestVersion())
+	}
+	if applied, err := db.Migrate(); err != nil || len(applied) != 0 {
+		t.Errorf("second migrate applied %v %v", applied, err)
+	}
+
+	if _, err := db.conn.Exec(fmt.Sprintf(`pragma user_version = %d`, LatestVersion()+1)); err != nil {
+		t.Fatal(err)
+	}
+	db.Close()
+	if _, err := Open(t.Context(), path); !errors.Is(err, ErrSchemaTooNew) {
+		t.Errorf("err = %v, want %v", err, ErrSchemaTooNew)
+	}
+}
+
+func TestRefreshFingerprints(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bank.db")
+	db, err := New(t.Context(), path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	html := `<p>Решите <span class="math-tex">\(x-2=5\)</span></p>`
+	err = db.SaveExercise(t.Context(), storage.Exercise{ID: 7, Content: html, Fingerprint: pl.Fingerprint(html)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// saved by a binary that dropped signs
+	if _, err := db.conn.Exec(`update exercises set fingerprint = 'old', fingerprint_version = 1 where id = 7`); err != nil {
+		t.Fatal(err)
+	}
+	db.Close()
+
+	db, err = New(t.Context(), path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	e, err := db.GetExercise(t.Context(), 7)
+	if err != nil || e == nil || e.Fingerprint != pl.Fingerprint(html) {
+		t.Errorf("exercise %+v %v", e, err)
+	}
+}
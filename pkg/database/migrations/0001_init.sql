@@ -0,0 +1,34 @@
+-- databases created before versioning already have these tables
+
+create table if not exists subjects (id integer primary key, name text);
+
+create table if not exists courses (
+    id integer primary key,
+    name text,
+
+    subject_id integer references subjects(id)
+);
+
+create table if not exists modules (
+    id integer primary key,
+    name text,
+
+    course_id integer references courses(id)
+);
+
+create table if not exists components (
+    id integer primary key,
+    name text,
+
+    module_id integer references modules(id)
+);
+
+create table if not exists questions (
+    id integer,
+    content text,
+    right_answer integer,
+
+    subject_id integer references subjects(id),
+    course_id integer references courses(id),
+    module_id integer references modules(id)
+);
@@ -0,0 +1,60 @@
+-- databases created before versioning already have these tables
+
+create table if not exists theory_chunks (
+    activity_id integer,
+    position integer,
+    content text,
+    embedding blob,
+    embedding_model text,
+    course_id integer,
+    module_id integer,
+
+    primary key (activity_id, module_id, position)
+);
+
+create table if not exists reasonings (
+    id integer primary key,
+    question_id integer,
+    model text,
+    reasoning text,
+    answer integer,
+    created_at timestamp default current_timestamp,
+    course_id integer,
+    module_id integer
+);
+
+create table if not exists solver_cache (
+    key text primary key,
+    model text,
+    answer integer,
+    content text,
+    created_at timestamp default current_timestamp,
+    expires_at timestamp
+);
+
+create table if not exists decisions (
+    id integer primary key,
+    question_id integer,
+    model text,
+    model_answer integer,
+    human_answer integer,
+    action text,
+    created_at timestamp default current_timestamp,
+    course_id integer,
+    module_id integer
+);
+
+create table if not exists mistakes (
+    id integer primary key,
+    question_id integer,
+    question text,
+    chosen_answer integer,
+    chosen_text text,
+    right_answer integer,
+    right_text text,
+    explanation text,
+    model text,
+    created_at timestamp default current_timestamp,
+    course_id integer,
+    module_id integer
+);
@@ -0,0 +1,46 @@
+-- exercises replace questions as the bank, options keep their text and
+-- correctness so multi-select answers and ids can be shown later.
+-- fingerprints are computed by the binary, rows of an older fingerprint
+-- version are computed again on open, question and answers text is plain
+-- text for search
+create table exercises (
+    id integer primary key,
+    kind text,
+    content text,
+    fingerprint text,
+    fingerprint_version integer not null default 1,
+    question_text text,
+    answers_text text,
+    subject_id integer,
+    course_id integer,
+    module_id integer,
+    created_at timestamp default current_timestamp,
+    updated_at timestamp default current_timestamp
+);
+
+create table answer_options (
+    exercise_id integer references exercises(id) on delete cascade,
+    answer_id integer,
+    position integer,
+    text text,
+    -- null until the platform tells which options are right
+    correct integer,
+
+    primary key (exercise_id, answer_id)
+);
+
+-- every submitted answer, session is plario attempt id, ids are json arrays
+create table attempts (
+    id integer primary key,
+    exercise_id integer references exercises(id),
+    session integer,
+    model text,
+    chosen_ids text,
+    correct_ids text,
+    correct integer,
+    created_at timestamp default current_timestamp
+);
+
+create index exercises_fingerprint on exercises (fingerprint);
+
+create index attempts_exercise on attempts (exercise_id);
@@ -0,0 +1,26 @@
+-- one row per run of the quiz loop, flags is json of flags set on the command line
+create table runs (
+    id integer primary key,
+    started_at timestamp default current_timestamp,
+    ended_at timestamp,
+    subject_id integer,
+    course_id integer,
+    module_id integer,
+    model text,
+    flags text,
+    exit_reason text,
+    correct integer default 0,
+    wrong integer default 0,
+    bank_hits integer default 0,
+    bank_misses integer default 0
+);
+
+create table mastery_samples (
+    id integer primary key,
+    run_id integer references runs(id),
+    module_id integer,
+    mastery real,
+    created_at timestamp default current_timestamp
+);
+
+create index mastery_samples_module on mastery_samples (module_id, created_at);
@@ -0,0 +1,12 @@
+-- spaced repetition state of exercises reviewed offline, times are utc
+create table review_cards (
+    exercise_id integer primary key references exercises(id) on delete cascade,
+    ease real not null,
+    interval_days integer not null,
+    repetitions integer not null,
+    lapses integer not null,
+    due_at timestamp not null,
+    reviewed_at timestamp not null
+);
+
+create index review_cards_due on review_cards (due_at);
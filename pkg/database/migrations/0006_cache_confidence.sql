@@ -0,0 +1,3 @@
+-- share of samples that agreed on a cached answer, older entries come from a
+-- single sample
+alter table solver_cache add column confidence real not null default 1;
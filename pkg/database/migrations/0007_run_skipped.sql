@@ -0,0 +1,3 @@
+-- exercises a run left unanswered because the answer was not reliable or the
+-- learner skipped it
+alter table runs add column skipped integer default 0;
@@ -0,0 +1,141 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"pkg/review"
+	"pkg/storage"
+	"time"
+)
+
+// review card times are stored as utc text so they compare as text
+const reviewTime = "2006-01-02 15:04:05"
+
+// ReviewItem is a bank exercise to review with its repetition card
+type ReviewItem struct {
+	storage.Exercise
+	Card review.Card
+	// Wrong is number of wrong answers to the exercise by the learner or models
+	Wrong int
+	// Explanation of the latest mistake on the exercise, empty when none was stored
+	Explanation string
+}
+
+type ReviewQuery struct {
+	SubjectID int
+	CourseID  int
+	ModuleID  int
+	// Now is the moment cards must be due by
+	Now time.Time
+	// Limit is maximum number of items, NewLimit maximum number of never
+	// reviewed ones among them
+	Limit    int
+	NewLimit int
+}
+
+// ReviewQueue returns verified single answer exercises to review: due cards
+// first, then never reviewed ones, in each group exercises answered wrong
+// more often come first
+func (db *DB) ReviewQueue(ctx context.Context, q ReviewQuery) ([]ReviewItem, error) {
+	query := `select e.id, c.exercise_id is not null, coalesce(c.ease, 0), coalesce(c.interval_days, 0),
+			coalesce(c.repetitions, 0), coalesce(c.lapses, 0), coalesce(c.due_at, ''), coalesce(c.reviewed_at, ''),
+			max((select count(*) from attempts a where a.exercise_id = e.id and a.correct = 0),
+				(select count(*) from mistakes m where m.question_id = e.id)) as wrong,
+			coalesce((select explanation from mistakes m where m.question_id = e.id and m.explanation != ''
+				order by m.created_at desc, m.id desc limit 1), '')
+		from exercises e left join review_cards c on c.exercise_id = e.id
+		where (select count(*) from answer_options o where o.exercise_id = e.id and o.correct = 1) = 1
+			and (?1 = 0 or e.subject_id = ?1) and (?2 = 0 or e.course_id = ?2) and (?3 = 0 or e.module_id = ?3)
+			and (c.exercise_id is null or c.due_at <= ?4)
+		order by c.exercise_id is null, wrong desc, c.due_at, e.id`
+
+	rows, err := db.conn.QueryContext(ctx, query, q.SubjectID, q.CourseID, q.ModuleID, q.Now.UTC().Format(reviewTime))
+	if err != nil {
+		return nil, fmt.Errorf("ReviewQueue: %s", err)
+	}
+
+	var items []ReviewItem
+	fresh := 0
+	for rows.Next() && len(items) < q.Limit {
+		var item ReviewItem
+		var reviewed bool
+		var due, last string
+		c := &item.Card
+		if err := rows.Scan(&c.ExerciseID, &reviewed, &c.Ease, &c.Interval, &c.Repetitions, &c.Lapses, &due, &last, &item.Wrong, &item.Explanation); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("ReviewQueue: %s", err)
+		}
+
+		if !reviewed {
+			if fresh >= q.NewLimit {
+				continue
+			}
+			fresh++
+			item.Card = review.NewCard(c.ExerciseID)
+		} else {
+			c.DueAt, _ = time.Parse(reviewTime, due)
+			c.ReviewedAt, _ = time.Parse(reviewTime, last)
+		}
+		items = append(items, item)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("ReviewQueue: %s", err)
+	}
+	if len(items) == 0 {
+		return nil, nil
+	}
+
+	ids := make([]int, 0, len(items))
+	for _, item := range items {
+		ids = append(ids, item.Card.ExerciseID)
+	}
+	filter, err := json.Marshal(ids)
+	if err != nil {
+		return nil, fmt.Errorf("ReviewQueue: %s", err)
+	}
+	exercises, err := db.queryExercises(ctx, `where id in (select value from json_each(?))`, string(filter))
+	if err != nil {
+		return nil, fmt.Errorf("ReviewQueue: %s", err)
+	}
+	byID := make(map[int]storage.Exercise, len(exercises))
+	for _, e := range exercises {
+		byID[e.ID] = e
+	}
+	for i := range items {
+		items[i].Exercise = byID[items[i].Card.ExerciseID]
+	}
+	return items, nil
+}
+
+// SaveCard stores repetition state of c.ExerciseID
+func (db *DB) SaveCard(ctx context.Context, c review.Card) error {
+	query := `insert into review_cards (exercise_id, ease, interval_days, repetitions, lapses, due_at, reviewed_at)
+		values (?, ?, ?, ?, ?, ?, ?)
+		on conflict (exercise_id) do update set ease = excluded.ease, interval_days = excluded.interval_days,
+			repetitions = excluded.repetitions, lapses = excluded.lapses, due_at = excluded.due_at, reviewed_at = excluded.reviewed_at`
+	_, err := db.conn.ExecContext(ctx, query, c.ExerciseID, c.Ease, c.Interval, c.Repetitions, c.Lapses,
+		c.DueAt.UTC().Format(reviewTime), c.ReviewedAt.UTC().Format(reviewTime))
+	if err != nil {
+		return fmt.Errorf("SaveCard: %s", err)
+	}
+	return nil
+}
+
+// NextDue returns when the earliest card is due, zero when no card is scheduled
+func (db *DB) NextDue(ctx context.Context) (time.Time, error) {
+	var due sql.NullString
+	if err := db.conn.QueryRowContext(ctx, `select min(due_at) from review_cards`).Scan(&due); err != nil {
+		return time.Time{}, fmt.Errorf("NextDue: %s", err)
+	}
+	if !due.Valid {
+		return time.Time{}, nil
+	}
+	t, err := time.Parse(reviewTime, due.String)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("NextDue: %s", err)
+	}
+	return t, nil
+}
@@ -0,0 +1,107 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"pkg/storage"
+	"time"
+)
+
+// StartRun stores a new run and returns its id
+func (db *DB) StartRun(ctx context.Context, r storage.Run) (int64, error) {
+	flags, err := json.Marshal(r.Flags)
+	if err != nil {
+		return 0, fmt.Errorf("StartRun: %s", err)
+	}
+
+	query := `insert into runs (subject_id, course_id, module_id, model, flags) values (?, ?, ?, ?, ?)`
+	res, err := db.conn.ExecContext(ctx, query, r.SubjectID, r.CourseID, r.ModuleID, r.Model, string(flags))
+	if err != nil {
+		return 0, fmt.Errorf("StartRun: %s", err)
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("StartRun: %s", err)
+	}
+	return id, nil
+}
+
+// FinishRun sets end time, exit reason and totals of run r.ID
+func (db *DB) FinishRun(ctx context.Context, r storage.Run) error {
+	query := `update runs set ended_at = current_timestamp, exit_reason = ?, correct = ?, wrong = ?, bank_hits = ?, bank_misses = ?, skipped = ? where id = ?`
+	if _, err := db.conn.ExecContext(ctx, query, r.ExitReason, r.Correct, r.Wrong, r.BankHits, r.BankMisses, r.Skipped, r.ID); err != nil {
+		return fmt.Errorf("FinishRun: %s", err)
+	}
+
+	return nil
+}
+
+// ListRuns returns runs of module (all modules when 0), newest first
+func (db *DB) ListRuns(ctx context.Context, moduleID int) ([]storage.Run, error) {
+	query := `select id, started_at, ended_at, subject_id, course_id, module_id, model, flags, coalesce(exit_reason, ''),
+		correct, wrong, bank_hits, bank_misses, skipped from runs
+		where (? = 0 or module_id = ?)
+		order by started_at desc, id desc`
+
+	rows, err := db.conn.QueryContext(ctx, query, moduleID, moduleID)
+	if err != nil {
+		return nil, fmt.Errorf("ListRuns: %s", err)
+	}
+	defer rows.Close()
+
+	var runs []storage.Run
+	for rows.Next() {
+		var r storage.Run
+		var ended sql.NullTime
+		var flags string
+		if err := rows.Scan(&r.ID, &r.StartedAt, &ended, &r.SubjectID, &r.CourseID, &r.ModuleID, &r.Model, &flags, &r.ExitReason,
+			&r.Correct, &r.Wrong, &r.BankHits, &r.BankMisses, &r.Skipped); err != nil {
+			return nil, fmt.Errorf("ListRuns: %s", err)
+		}
+		r.EndedAt = ended.Time
+		if err := json.Unmarshal([]byte(flags), &r.Flags); err != nil {
+			return nil, fmt.Errorf("ListRuns: %s", err)
+		}
+		runs = append(runs, r)
+	}
+
+	return runs, rows.Err()
+}
+
+func (db *DB) CreateMasterySample(ctx context.Context, s storage.MasterySample) error {
+	query := `insert into mastery_samples (run_id, module_id, mastery) values (?, ?, ?)`
+	if _, err := db.conn.ExecContext(ctx, query, s.RunID, s.ModuleID, s.Mastery); err != nil {
+		return fmt.Errorf("CreateMasterySample: %s", err)
+	}
+
+	return nil
+}
+
+// ListMasterySamples returns mastery readings of module (all modules when 0)
+// taken after since, oldest first
+func (db *DB) ListMasterySamples(ctx context.Context, moduleID int, since time.Time) ([]storage.MasterySample, error) {
+	query := `select run_id, module_id, mastery, created_at from mastery_samples
+		where (? = 0 or module_id = ?) and created_at >= ?
+		order by created_at, id`
+
+	// created_at is sqlite current_timestamp text, compared as text
+	rows, err := db.conn.QueryContext(ctx, query, moduleID, moduleID, since.UTC().Format("2006-01-02 15:04:05"))
+	if err != nil {
+		return nil, fmt.Errorf("ListMasterySamples: %s", err)
+	}
+	defer rows.Close()
+
+	var samples []storage.MasterySample
+	for rows.Next() {
+		var s storage.MasterySample
+		if err := rows.Scan(&s.RunID, &s.ModuleID, &s.Mastery, &s.CreatedAt); err != nil {
+			return nil, fmt.Errorf("ListMasterySamples: %s", err)
+		}
+		samples = append(samples, s)
+	}
+
+	return samples, rows.Err()
+}
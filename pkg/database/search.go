@@ -0,0 +1,212 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"pkg/storage"
+	"strings"
+)
+
+// bank_search is not a migration, fts5 is only compiled in with
+// -tags sqlite_fts5 and the same database may be opened by builds without it
+var searchTriggers = []string{
+	`create trigger bank_search_insert after insert on exercises begin
+		insert into bank_search (rowid, question, answers, explanations)
+		values (new.id, new.question_text, new.answers_text, (select group_concat(explanation, char(10)) from mistakes where question_id = new.id));
+	end`,
+	`create trigger bank_search_update after update on exercises begin
+		delete from bank_search where rowid = old.id;
+		insert into bank_search (rowid, question, answers, explanations)
+		values (new.id, new.question_text, new.answers_text, (select group_concat(explanation, char(10)) from mistakes where question_id = new.id));
+	end`,
+	`create trigger bank_search_mistake after insert on mistakes begin
+		update bank_search set explanations = (select group_concat(explanation, char(10)) from mistakes where question_id = new.question_id)
+		where rowid = new.question_id;
+	end`,
+}
+
+// ensureSearch keeps fts5 index of the bank in sync by triggers when sqlite
+// has fts5. Without it triggers are dropped, they would fail on every write,
+// and the index is rebuilt next time a build with fts5 opens the database.
+func (db *DB) ensureSearch(ctx context.Context) error {
+	var fts int
+	if err := db.conn.QueryRowContext(ctx, `select sqlite_compileoption_used('ENABLE_FTS5')`).Scan(&fts); err != nil {
+		return fmt.Errorf("ensureSearch: %s", err)
+	}
+
+	tx, err := db.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("ensureSearch: %s", err)
+	}
+	defer tx.Rollback()
+
+	if fts == 0 {
+		for _, name := range []string{"bank_search_insert", "bank_search_update", "bank_search_mistake"} {
+			if _, err := tx.ExecContext(ctx, `drop trigger if exists `+name); err != nil {
+				return fmt.Errorf("ensureSearch: %s", err)
+			}
+		}
+		return tx.Commit()
+	}
+
+	var triggers int
+	if err := tx.QueryRowContext(ctx, `select count(*) from sqlite_master where type = 'trigger' and name like 'bank_search_%'`).Scan(&triggers); err != nil {
+		return fmt.Errorf("ensureSearch: %s", err)
+	}
+	if triggers < len(searchTriggers) {
+		stmts := []string{
+			`create virtual table if not exists bank_search using fts5 (question, answers, explanations)`,
+			`drop trigger if exists bank_search_insert`,
+			`drop trigger if exists bank_search_update`,
+			`drop trigger if exists bank_search_mistake`,
+		}
+		stmts = append(stmts, searchTriggers...)
+		stmts = append(stmts,
+			`delete from bank_search`,
+			`insert into bank_search (rowid, question, answers, explanations)
+			select id, question_text, answers_text, (select group_concat(explanation, char(10)) from mistakes where question_id = exercises.id)
+			from exercises`,
+		)
+		for _, s := range stmts {
+			if _, err := tx.ExecContext(ctx, s); err != nil {
+				return fmt.Errorf("ensureSearch: %s", err)
+			}
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("ensureSearch: %s", err)
+	}
+	db.fts = true
+	return nil
+}
+
+type SearchQuery struct {
+	Text      string
+	SubjectID int
+	CourseID  int
+	ModuleID  int
+	Limit     int
+}
+
+type SearchHit struct {
+	storage.Exercise
+	ModuleName string
+	// Snippet is matched text with terms in [brackets], empty without fts5
+	Snippet string
+}
+
+// SearchBank finds exercises whose question, answers or mistake explanations
+// contain every word of q.Text, best match first. Uses fts5 index when
+// available and a slower like scan otherwise.
+func (db *DB) SearchBank(ctx context.Context, q SearchQuery) ([]SearchHit, error) {
+	words := strings.Fields(q.Text)
+	if len(words) == 0 {
+		return nil, nil
+	}
+	if q.Limit <= 0 {
+		q.Limit = 20
+	}
+
+	var query string
+	var args []any
+	if db.fts {
+		terms := make([]string, len(words))
+		for i, w := range words {
+			terms[i] = `"` + strings.ReplaceAll(w, `"`, `""`) + `"*`
+		}
+		query = `select e.id, snippet(bank_search, -1, '[', ']', '…', 12) from bank_search
+			join exercises e on e.id = bank_search.rowid
+			where bank_search match ?1`
+		args = append(args, strings.Join(terms, " "))
+	} else {
+		query = `select e.id, '' from exercises e where 1 = 1`
+		for _, w := range words {
+			args = append(args, "%"+w+"%")
+			query += fmt.Sprintf(` and (e.question_text like ?%[1]d or e.answers_text like ?%[1]d
+				or exists (select 1 from mistakes m where m.question_id = e.id and m.explanation like ?%[1]d))`, len(args))
+		}
+	}
+
+	n := len(args)
+	query += fmt.Sprintf(` and (?%d = 0 or e.subject_id = ?%d) and (?%d = 0 or e.course_id = ?%d) and (?%d = 0 or e.module_id = ?%d)`,
+		n+1, n+1, n+2, n+2, n+3, n+3)
+	args = append(args, q.SubjectID, q.CourseID, q.ModuleID)
+	if db.fts {
+		query += ` order by rank`
+	} else {
+		query += ` order by e.updated_at desc`
+	}
+	query += fmt.Sprintf(` limit ?%d`, n+4)
+	args = append(args, q.Limit)
+
+	rows, err := db.conn.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("SearchBank: %s", err)
+	}
+
+	var ids []int
+	snippets := make(map[int]string)
+	for rows.Next() {
+		var id int
+		var snippet string
+		if err := rows.Scan(&id, &snippet); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("SearchBank: %s", err)
+		}
+		ids = append(ids, id)
+		snippets[id] = snippet
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("SearchBank: %s", err)
+	}
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	filter, err := json.Marshal(ids)
+	if err != nil {
+		return nil, fmt.Errorf("SearchBank: %s", err)
+	}
+	exercises, err := db.queryExercises(ctx, `where id in (select value from json_each(?))`, string(filter))
+	if err != nil {
+		return nil, fmt.Errorf("SearchBank: %s", err)
+	}
+	byID := make(map[int]storage.Exercise, len(exercises))
+	for _, e := range exercises {
+		byID[e.ID] = e
+	}
+
+	names, err := db.moduleNames(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("SearchBank: %s", err)
+	}
+
+	hits := make([]SearchHit, 0, len(ids))
+	for _, id := range ids {
+		e := byID[id]
+		hits = append(hits, SearchHit{Exercise: e, ModuleName: names[e.ModuleID], Snippet: snippets[id]})
+	}
+	return hits, nil
+}
+
+func (db *DB) moduleNames(ctx context.Context) (map[int]string, error) {
+	rows, err := db.conn.QueryContext(ctx, `select id, coalesce(name, '') from modules`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	names := make(map[int]string)
+	for rows.Next() {
+		var id int
+		var name string
+		if err := rows.Scan(&id, &name); err != nil {
+			return nil, err
+		}
+		names[id] = name
+	}
+	return names, rows.Err()
+}
@@ -9,6 +9,8 @@ import (
 	"net/http"
 )
 
+const groqBaseURL = "https://api.groq.com/openai/v1"
+
 var (
 	ErrLimitReached = fmt.Errorf("today's limit for model expired")
 )
@@ -17,7 +19,11 @@ type Groq struct {
 	Token        string
 	Model        Model
 	Instructions string
-	logger       *slog.Logger
+	// Reasoning asks reasoning models to return their thoughts in Message.Reasoning
+	Reasoning bool
+	// Temperature is provider default when nil
+	Temperature *float64
+	logger      *slog.Logger
 }
 
 func NewGroq(token string, model Model, instructions string, logger *slog.Logger) *Groq {
@@ -30,13 +36,19 @@ func NewGroq(token string, model Model, instructions string, logger *slog.Logger
 }
 
 func (g *Groq) SendGroqRequest(client *http.Client, question string) (*GroqResponse, error) {
+	return g.Complete(client, []Message{
+		{Role: "system", Content: g.Instructions},
+		{Role: "user", Content: question},
+	})
+}
+
+// Complete sends messages as is, caller is responsible for system prompt
+func (g *Groq) Complete(client *http.Client, messages []Message) (*GroqResponse, error) {
 	reqBody := GroqRequest{
 		Model:            string(g.Model),
-		IncludeReasoning: false,
-		Messages: []Message{
-			{Role: "system", Content: g.Instructions},
-			{Role: "user", Content: question},
-		},
+		IncludeReasoning: g.Reasoning,
+		Temperature:      g.Temperature,
+		Messages:         messages,
 	}
 
 	b, err := json.Marshal(reqBody)
@@ -44,7 +56,7 @@ func (g *Groq) SendGroqRequest(client *http.Client, question string) (*GroqRespo
 		return nil, err
 	}
 
-	req, err := http.NewRequest("POST", "https://api.groq.com/openai/v1/chat/completions", bytes.NewReader(b))
+	req, err := http.NewRequest("POST", groqBaseURL+"/chat/completions", bytes.NewReader(b))
 	if err != nil {
 		return nil, err
 	}
@@ -77,6 +89,7 @@ func (g *Groq) SendGroqRequest(client *http.Client, question string) (*GroqRespo
 type GroqRequest struct {
 	Model            string    `json:"model"`
 	IncludeReasoning bool      `json:"include_reasoning"`
+	Temperature      *float64  `json:"temperature,omitempty"`
 	Messages         []Message `json:"messages"`
 }
 
@@ -92,6 +105,7 @@ type Choice struct {
 }
 
 type Message struct {
-	Role    string `json:"role"`
-	Content string `json:"content"`
+	Role      string `json:"role"`
+	Content   string `json:"content"`
+	Reasoning string `json:"reasoning,omitempty"`
 }
@@ -1,26 +1,32 @@
 package llm
 
-import "fmt"
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+var (
+	ErrUnknownModel = errors.New("unknown model")
+	ErrNotChatModel = errors.New("model can not answer chat completions")
+)
+
+const (
+	// CatalogTTL is how long a cached model catalog is trusted before refetching
+	CatalogTTL = 24 * time.Hour
+)
 
 type Model string
 
 const (
-	ModelAllam27B                      Model = "allam-2-7b"
-	ModelGroqCompound                  Model = "groq/compound"
-	ModelGroqCompoundMini              Model = "groq/compound-mini"
-	ModelLlama318BInstant              Model = "llama-3.1-8b-instant"
-	ModelLlama3370BVersatile           Model = "llama-3.3-70b-versatile"
-	ModelLlama4Maverick17B128EInstruct Model = "meta-llama/llama-4-maverick-17b-128e-instruct"
-	ModelLlama4Scout17B16EInstruct     Model = "meta-llama/llama-4-scout-17b-16e-instruct"
-	ModelLlamaGuard412B                Model = "meta-llama/llama-guard-4-12b"
-	ModelLlamaPromptGuard222M          Model = "meta-llama/llama-prompt-guard-2-22m"
-	ModelLlamaPromptGuard286M          Model = "meta-llama/llama-prompt-guard-2-86m"
-	ModelKimiK2Instruct                Model = "moonshotai/kimi-k2-instruct"
-	ModelKimiK2Instruct0905            Model = "moonshotai/kimi-k2-instruct-0905"
-	ModelOpenAIGptOss120B              Model = "openai/gpt-oss-120b"
-	ModelOpenAIGptOss20B               Model = "openai/gpt-oss-20b"
-	ModelOpenAIGptOssSafeguard20B      Model = "openai/gpt-oss-safeguard-20b"
-	ModelQwen32B                       Model = "qwen/qwen3-32b"
+	ModelOpenAIGptOss120B Model = "openai/gpt-oss-120b"
 )
 
 func (m *Model) String() string {
@@ -31,34 +37,191 @@ func (m *Model) Set(value string) error {
 	return nil
 }
 
-var modelSet = map[Model]struct{}{
-	ModelAllam27B:                      {},
-	ModelGroqCompound:                  {},
-	ModelGroqCompoundMini:              {},
-	ModelLlama318BInstant:              {},
-	ModelLlama3370BVersatile:           {},
-	ModelLlama4Maverick17B128EInstruct: {},
-	ModelLlama4Scout17B16EInstruct:     {},
-	ModelLlamaGuard412B:                {},
-	ModelLlamaPromptGuard222M:          {},
-	ModelLlamaPromptGuard286M:          {},
-	ModelKimiK2Instruct:                {},
-	ModelKimiK2Instruct0905:            {},
-	ModelOpenAIGptOss120B:              {},
-	ModelOpenAIGptOss20B:               {},
-	ModelOpenAIGptOssSafeguard20B:      {},
-	ModelQwen32B:                       {},
+type Capabilities struct {
+	Chat       bool `json:"chat"`
+	Classifier bool `json:"classifier"`
+	Vision     bool `json:"vision"`
+	JSONMode   bool `json:"json_mode"`
+	Reasoning  bool `json:"reasoning"`
+}
+
+type ModelInfo struct {
+	ID                  Model        `json:"id"`
+	OwnedBy             string       `json:"owned_by"`
+	Active              bool         `json:"active"`
+	ContextWindow       int          `json:"context_window"`
+	MaxCompletionTokens int          `json:"max_completion_tokens"`
+	Capabilities        Capabilities `json:"capabilities"`
 }
 
-func (m Model) IsValid() bool {
-	_, ok := modelSet[m]
-	return ok
+type Catalog struct {
+	FetchedAt time.Time   `json:"fetched_at"`
+	Models    []ModelInfo `json:"models"`
+}
+
+func (c *Catalog) Lookup(m Model) (ModelInfo, bool) {
+	for _, info := range c.Models {
+		if info.ID == m {
+			return info, true
+		}
+	}
+	return ModelInfo{}, false
+}
+
+// Validate checks that model exists in catalog and is able to answer quizzes
+func (c *Catalog) Validate(m Model) error {
+	info, ok := c.Lookup(m)
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownModel, m)
+	}
+	if !info.Active || !info.Capabilities.Chat {
+		return fmt.Errorf("%w: %s", ErrNotChatModel, m)
+	}
+	return nil
 }
 
-func All() []Model {
-	models := make([]Model, 0, len(modelSet))
-	for m := range modelSet {
-		models = append(models, m)
+// Chat returns active models capable of chat completions
+func (c *Catalog) Chat() []ModelInfo {
+	var models []ModelInfo
+	for _, info := range c.Models {
+		if info.Active && info.Capabilities.Chat {
+			models = append(models, info)
+		}
 	}
 	return models
 }
+
+func (c *Catalog) ChatIDs() []Model {
+	var ids []Model
+	for _, info := range c.Chat() {
+		ids = append(ids, info.ID)
+	}
+	return ids
+}
+
+func (c *Catalog) Expired(ttl time.Duration) bool {
+	return time.Since(c.FetchedAt) > ttl
+}
+
+func DefaultCatalogPath() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		dir = os.TempDir()
+	}
+	return filepath.Join(dir, "plario", "models.json")
+}
+
+// LoadCatalog returns cached catalog if it is fresh, otherwise fetches it from groq
+// and rewrites the cache. When fetching fails but a stale cache exists, the stale
+// catalog is returned together with the fetch error.
+func LoadCatalog(client *http.Client, token, cachePath string, refresh bool) (*Catalog, error) {
+	cached, cacheErr := ReadCatalog(cachePath)
+	if cacheErr == nil && !refresh && !cached.Expired(CatalogTTL) {
+		return cached, nil
+	}
+
+	fresh, err := FetchCatalog(client, token)
+	if err != nil {
+		if cacheErr == nil {
+			return cached, err
+		}
+		return nil, err
+	}
+
+	if err := WriteCatalog(cachePath, fresh); err != nil {
+		return fresh, err
+	}
+	return fresh, nil
+}
+
+func ReadCatalog(path string) (*Catalog, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var c Catalog
+	if err := json.Unmarshal(b, &c); err != nil {
+		return nil, fmt.Errorf("ReadCatalog: %s", err)
+	}
+	return &c, nil
+}
+
+func WriteCatalog(path string, c *Catalog) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+
+	b, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, b, 0o644)
+}
+
+func FetchCatalog(client *http.Client, token string) (*Catalog, error) {
+	req, err := http.NewRequest("GET", groqBaseURL+"/models", nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("FetchCatalog: bad status code %d: %s", resp.StatusCode, string(body))
+	}
+
+	var list struct {
+		Data []ModelInfo `json:"data"`
+	}
+	if err := json.Unmarshal(body, &list); err != nil {
+		return nil, fmt.Errorf("err unmarshaling %s", string(body))
+	}
+
+	for i := range list.Data {
+		list.Data[i].Capabilities = inferCapabilities(string(list.Data[i].ID))
+	}
+	slices.SortFunc(list.Data, func(a, b ModelInfo) int {
+		return strings.Compare(string(a.ID), string(b.ID))
+	})
+
+	return &Catalog{FetchedAt: time.Now(), Models: list.Data}, nil
+}
+
+// /models endpoint only tells ids and limits, capabilities are derived from
+// well known model families
+func inferCapabilities(id string) Capabilities {
+	id = strings.ToLower(id)
+
+	for _, marker := range []string{"guard", "whisper", "tts", "orpheus", "embed"} {
+		if strings.Contains(id, marker) {
+			return Capabilities{Classifier: strings.Contains(id, "guard")}
+		}
+	}
+
+	c := Capabilities{Chat: true, JSONMode: true}
+	if strings.Contains(id, "compound") {
+		c.JSONMode = false
+	}
+	for _, marker := range []string{"llama-4", "vision", "-vl"} {
+		if strings.Contains(id, marker) {
+			c.Vision = true
+		}
+	}
+	for _, marker := range []string{"gpt-oss", "qwen3", "deepseek-r1", "qwq", "compound"} {
+		if strings.Contains(id, marker) {
+			c.Reasoning = true
+		}
+	}
+	return c
+}
@@ -0,0 +1,86 @@
+// Package content parses Plario exercise and answer html into a document
+// tree and renders it back as plain text, markdown, html or json.
+package content
+
+import (
+	"encoding/json"
+)
+
+type Kind string
+
+const (
+	Document  Kind = "document"
+	Paragraph Kind = "paragraph"
+	Heading   Kind = "heading"
+	Quote     Kind = "quote"
+	List      Kind = "list"
+	Item      Kind = "item"
+	Table     Kind = "table"
+	Row       Kind = "row"
+	Cell      Kind = "cell"
+	Code      Kind = "code"
+	Formula   Kind = "formula"
+	Image     Kind = "image"
+	Link      Kind = "link"
+	Text      Kind = "text"
+	Strong    Kind = "strong"
+	Emphasis  Kind = "emphasis"
+	Sup       Kind = "sup"
+	Sub       Kind = "sub"
+	Break     Kind = "break"
+)
+
+type Node struct {
+	Kind Kind `json:"kind"`
+
+	// Value is text of Text, tex of Formula without delimiters and source of Code
+	Value string `json:"value,omitempty"`
+	// Display marks block Formula ($$…$$) and block Code (<pre>)
+	Display bool `json:"display,omitempty"`
+	// Ordered marks numbered List
+	Ordered bool `json:"ordered,omitempty"`
+	// Header marks header Row and Cell
+	Header bool `json:"header,omitempty"`
+	// Level is Heading level 1-6
+	Level int `json:"level,omitempty"`
+
+	Src  string `json:"src,omitempty"`
+	Alt  string `json:"alt,omitempty"`
+	Href string `json:"href,omitempty"`
+
+	Children []*Node `json:"children,omitempty"`
+}
+
+// Walk visits n and its descendants depth first until fn returns false
+func (n *Node) Walk(fn func(*Node) bool) bool {
+	if !fn(n) {
+		return false
+	}
+	for _, c := range n.Children {
+		if !c.Walk(fn) {
+			return false
+		}
+	}
+	return true
+}
+
+// Find returns all descendants of kind, n included
+func (n *Node) Find(kind Kind) []*Node {
+	var found []*Node
+	n.Walk(func(c *Node) bool {
+		if c.Kind == kind {
+			found = append(found, c)
+		}
+		return true
+	})
+	return found
+}
+
+// Has reports whether tree contains a node of kind
+func (n *Node) Has(kind Kind) bool {
+	return !n.Walk(func(c *Node) bool { return c.Kind != kind })
+}
+
+func (n *Node) JSON() ([]byte, error) {
+	return json.Marshal(n)
+}
@@ -0,0 +1,94 @@
+package content
+
+import (
+	"html"
+	"strconv"
+	"strings"
+)
+
+// HTML renders tree as clean html without platform classes and styles, math
+// is left for MathJax as \(…\) (\[…\] for display math)
+func (n *Node) HTML() string {
+	var b strings.Builder
+	writeHTML(&b, n)
+	return strings.TrimSpace(b.String())
+}
+
+func writeHTML(b *strings.Builder, n *Node) {
+	wrap := func(tag string) {
+		b.WriteString("<" + tag + ">")
+		for _, c := range n.Children {
+			writeHTML(b, c)
+		}
+		b.WriteString("</" + tag + ">")
+	}
+
+	switch n.Kind {
+	case Text:
+		b.WriteString(html.EscapeString(n.Value))
+	case Break:
+		b.WriteString("<br>")
+	case Paragraph:
+		wrap("p")
+	case Item:
+		wrap("li")
+	case Row:
+		wrap("tr")
+	case Cell:
+		if n.Header {
+			wrap("th")
+		} else {
+			wrap("td")
+		}
+	case Heading:
+		wrap("h" + strconv.Itoa(min(max(n.Level, 1), 6)))
+	case Quote:
+		wrap("blockquote")
+	case List:
+		if n.Ordered {
+			wrap("ol")
+		} else {
+			wrap("ul")
+		}
+	case Table:
+		wrap("table")
+	case Strong:
+		wrap("b")
+	case Emphasis:
+		wrap("i")
+	case Sup:
+		wrap("sup")
+	case Sub:
+		wrap("sub")
+	case Formula:
+		if n.Display {
+			b.WriteString(`\[` + html.EscapeString(n.Value) + `\]`)
+		} else {
+			b.WriteString(`\(` + html.EscapeString(n.Value) + `\)`)
+		}
+	case Code:
+		if n.Display {
+			b.WriteString("<pre>" + html.EscapeString(n.Value) + "</pre>")
+		} else {
+			b.WriteString("<code>" + html.EscapeString(n.Value) + "</code>")
+		}
+	case Image:
+		b.WriteString(`<img src="` + html.EscapeString(n.Src) + `" alt="` + html.EscapeString(n.Alt) + `">`)
+	case Link:
+		if n.Href == "" {
+			for _, c := range n.Children {
+				writeHTML(b, c)
+			}
+			return
+		}
+		b.WriteString(`<a href="` + html.EscapeString(n.Href) + `">`)
+		for _, c := range n.Children {
+			writeHTML(b, c)
+		}
+		b.WriteString("</a>")
+	default:
+		for _, c := range n.Children {
+			writeHTML(b, c)
+		}
+	}
+}
@@ -0,0 +1,210 @@
+package content
+
+import (
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+var (
+	spaces = regexp.MustCompile(`[ \t\r\n\f]+`)
+	tags   = regexp.MustCompile(`<[^>]*>`)
+
+	// mathjax accepts $$…$$, \[…\], $…$ and \(…\), longest delimiters go first
+	math = regexp.MustCompile(`(?s)\$\$(.+?)\$\$|\\\[(.+?)\\\]|\$([^$]+?)\$|\\\((.+?)\\\)`)
+)
+
+// Parse never fails: malformed html is repaired by the html5 parser and
+// anything it can not handle ends up as plain text
+func Parse(s string) (doc *Node) {
+	defer func() {
+		if r := recover(); r != nil {
+			doc = fallback(s)
+		}
+	}()
+
+	body := &html.Node{Type: html.ElementNode, Data: "body", DataAtom: atom.Body}
+	nodes, err := html.ParseFragment(strings.NewReader(s), body)
+	if err != nil {
+		return fallback(s)
+	}
+
+	doc = &Node{Kind: Document}
+	for _, n := range nodes {
+		doc.Children = append(doc.Children, convert(n)...)
+	}
+	return doc
+}
+
+func fallback(s string) *Node {
+	text := html.UnescapeString(tags.ReplaceAllString(s, " "))
+	return &Node{Kind: Document, Children: []*Node{
+		{Kind: Paragraph, Children: splitMath(spaces.ReplaceAllString(text, " "))},
+	}}
+}
+
+// splitMath cuts text into Text and Formula nodes
+func splitMath(s string) []*Node {
+	var nodes []*Node
+	last := 0
+	for _, m := range math.FindAllStringSubmatchIndex(s, -1) {
+		if m[0] > last {
+			nodes = append(nodes, &Node{Kind: Text, Value: s[last:m[0]]})
+		}
+		for g := 1; g <= 4; g++ {
+			if m[2*g] >= 0 {
+				nodes = append(nodes, &Node{
+					Kind:    Formula,
+					Value:   strings.TrimSpace(s[m[2*g]:m[2*g+1]]),
+					Display: g <= 2,
+				})
+				break
+			}
+		}
+		last = m[1]
+	}
+	if last < len(s) {
+		nodes = append(nodes, &Node{Kind: Text, Value: s[last:]})
+	}
+	return nodes
+}
+
+func attr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+func textContent(n *html.Node) string {
+	if n.Type == html.TextNode {
+		return n.Data
+	}
+	var b strings.Builder
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		b.WriteString(textContent(c))
+	}
+	return b.String()
+}
+
+// mathElement recognizes mathjax/katex wrappers and <script type="math/tex">
+func mathElement(n *html.Node) (ok, display bool) {
+	class := attr(n, "class")
+	switch {
+	case n.DataAtom == atom.Script && strings.HasPrefix(attr(n, "type"), "math/tex"):
+		return true, strings.Contains(attr(n, "type"), "mode=display")
+	case strings.Contains(class, "math-tex"), strings.Contains(class, "katex"), strings.Contains(class, "MathJax"):
+		return true, strings.Contains(class, "display")
+	}
+	return false, false
+}
+
+func children(n *html.Node) []*Node {
+	var nodes []*Node
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		nodes = append(nodes, convert(c)...)
+	}
+	return nodes
+}
+
+func element(kind Kind, n *html.Node) []*Node {
+	return []*Node{{Kind: kind, Children: children(n)}}
+}
+
+func convert(n *html.Node) []*Node {
+	switch n.Type {
+	case html.TextNode:
+		return splitMath(spaces.ReplaceAllString(n.Data, " "))
+	case html.DocumentNode:
+		return children(n)
+	case html.ElementNode:
+	default:
+		return nil
+	}
+
+	if ok, display := mathElement(n); ok {
+		tex := strings.TrimSpace(textContent(n))
+		if parts := splitMath(tex); len(parts) == 1 && parts[0].Kind == Formula {
+			tex = parts[0].Value
+			display = display || parts[0].Display
+		}
+		return []*Node{{Kind: Formula, Value: tex, Display: display}}
+	}
+
+	switch n.DataAtom {
+	case atom.Style, atom.Script, atom.Head, atom.Title, atom.Meta:
+		return nil
+	case atom.Br:
+		return []*Node{{Kind: Break}}
+	case atom.P, atom.Div, atom.Section, atom.Article, atom.Header, atom.Footer:
+		return element(Paragraph, n)
+	case atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6:
+		return []*Node{{Kind: Heading, Level: int(n.Data[1] - '0'), Children: children(n)}}
+	case atom.Strong, atom.B:
+		return element(Strong, n)
+	case atom.Em, atom.I:
+		return element(Emphasis, n)
+	case atom.Sup:
+		return element(Sup, n)
+	case atom.Sub:
+		return element(Sub, n)
+	case atom.Code, atom.Kbd, atom.Samp:
+		return []*Node{{Kind: Code, Value: textContent(n)}}
+	case atom.Pre:
+		return []*Node{{Kind: Code, Value: strings.Trim(textContent(n), "\n"), Display: true}}
+	case atom.Img:
+		return []*Node{{Kind: Image, Src: attr(n, "src"), Alt: attr(n, "alt")}}
+	case atom.A:
+		return []*Node{{Kind: Link, Href: attr(n, "href"), Children: children(n)}}
+	case atom.Blockquote:
+		return element(Quote, n)
+	case atom.Ul, atom.Ol:
+		list := &Node{Kind: List, Ordered: n.DataAtom == atom.Ol}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			if c.DataAtom == atom.Li {
+				list.Children = append(list.Children, element(Item, c)...)
+			}
+		}
+		return []*Node{list}
+	case atom.Table:
+		return []*Node{table(n)}
+	}
+
+	return children(n)
+}
+
+func table(n *html.Node) *Node {
+	t := &Node{Kind: Table}
+
+	var walk func(*html.Node, bool)
+	walk = func(n *html.Node, head bool) {
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			switch c.DataAtom {
+			case atom.Tr:
+				row := &Node{Kind: Row, Header: head}
+				allHeader := true
+				for cell := c.FirstChild; cell != nil; cell = cell.NextSibling {
+					if cell.DataAtom != atom.Td && cell.DataAtom != atom.Th {
+						continue
+					}
+					isHeader := head || cell.DataAtom == atom.Th
+					allHeader = allHeader && isHeader
+					row.Children = append(row.Children, &Node{Kind: Cell, Header: isHeader, Children: children(cell)})
+				}
+				row.Header = len(row.Children) > 0 && allHeader
+				t.Children = append(t.Children, row)
+			case atom.Thead:
+				walk(c, true)
+			case atom.Tbody, atom.Tfoot:
+				walk(c, head)
+			}
+		}
+	}
+	walk(n, false)
+
+	return t
+}
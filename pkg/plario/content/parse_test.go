@@ -0,0 +1,138 @@
+package content
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+func TestRender(t *testing.T) {
+	tests := []struct {
+		name                       string
+		html                       string
+		wantText, wantMD, wantHTML string
+	}{
+		{
+			name:     "mathjax span",
+			html:     `<p>Найдите <span class="math-tex">\(x^2+1\)</span></p>`,
+			wantText: "Найдите x^2+1",
+			wantMD:   "Найдите $x^2+1$",
+			wantHTML: `<p>Найдите \(x^2+1\)</p>`,
+		},
+		{
+			name:     "display math",
+			html:     `<p>Вычислите $$\frac{1}{2}$$</p>`,
+			wantText: `Вычислите` + "\n\n" + `\frac{1}{2}`,
+			wantMD:   `Вычислите` + "\n\n" + `$$\frac{1}{2}$$`,
+			wantHTML: `<p>Вычислите \[\frac{1}{2}\]</p>`,
+		},
+		{
+			name:     "scripts",
+			html:     `<p>a<sup>2</sup> + b<sub>i</sub></p>`,
+			wantText: "a^2 + b_i",
+			wantMD:   "a^{2} + b_{i}",
+			wantHTML: `<p>a<sup>2</sup> + b<sub>i</sub></p>`,
+		},
+		{
+			name:     "list",
+			html:     `<ul><li>один</li><li>два</li></ul>`,
+			wantText: "- один\n- два",
+			wantMD:   "- один\n- два",
+			wantHTML: `<ul><li>один</li><li>два</li></ul>`,
+		},
+		{
+			name:     "table",
+			html:     `<table><tr><th>x</th><th>y</th></tr><tr><td>1</td><td>2</td></tr></table>`,
+			wantText: "x\ty\n1\t2",
+			wantMD:   "| x | y |\n| --- | --- |\n| 1 | 2 |",
+			wantHTML: `<table><tr><th>x</th><th>y</th></tr><tr><td>1</td><td>2</td></tr></table>`,
+		},
+		{
+			name:     "unclosed delimiter stays text",
+			html:     `цена $5 и \(x`,
+			wantText: `цена $5 и \(x`,
+			wantMD:   `цена $5 и \(x`,
+			wantHTML: `цена $5 и \(x`,
+		},
+		{
+			name:     "image",
+			html:     `<img src="a.png" alt="график">`,
+			wantText: "[image: график]",
+			wantMD:   "![график](a.png)",
+			wantHTML: `<img src="a.png" alt="график">`,
+		},
+		{
+			name:     "html is escaped",
+			html:     `<p>1 &lt; 2 &amp; "x"</p>`,
+			wantText: `1 < 2 & "x"`,
+			wantMD:   `1 < 2 & "x"`,
+			wantHTML: `<p>1 &lt; 2 &amp; &#34;x&#34;</p>`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc := Parse(tt.html)
+			if got := doc.Text(); got != tt.wantText {
+				t.Errorf("Text() = %q, want %q", got, tt.wantText)
+			}
+			if got := doc.Markdown(); got != tt.wantMD {
+				t.Errorf("Markdown() = %q, want %q", got, tt.wantMD)
+			}
+			if got := doc.HTML(); got != tt.wantHTML {
+				t.Errorf("HTML() = %q, want %q", got, tt.wantHTML)
+			}
+		})
+	}
+}
+
+func FuzzParse(f *testing.F) {
+	seeds := []string{
+		`<p>Найдите значение выражения <span class="math-tex">\(\frac{3}{4}+\sqrt{16}\)</span></p>`,
+		`<p>Решите уравнение $$x^2-5x+6=0$$</p><p><img src="/files/graph.png" alt=""></p>`,
+		`<div><p>Вложенный <b>жирный <i>курсив</b> без закрытия`,
+		`<table><tr><td>1<td>2<tr><td>3</table>`,
+		`<ul><li>один<li>два<ol><li>вложенный</ul>`,
+		`цена $5, а скидка $`,
+		`\(x+1 без закрытия`,
+		`\[ \]`,
+		`$$$$`,
+		`<span class="katex">x</span><span class="MathJax">\(</span>`,
+		`<p>a<sup>2<sub>i</sup></sub></p>`,
+		`<pre><code>if a < b {}</code></pre>`,
+		`<a href="javascript:alert(1)">ссылка</a>`,
+		`</p></div><<>>&&amp;&#xZZ;`,
+		"",
+		"\x00\xff",
+	}
+	for _, s := range seeds {
+		f.Add(s)
+	}
+
+	f.Fuzz(func(t *testing.T, s string) {
+		start := time.Now()
+		defer func() {
+			if d := time.Since(start); d > time.Second {
+				t.Errorf("rendering %d bytes took %s", len(s), d)
+			}
+		}()
+
+		doc := Parse(s)
+		if doc == nil || doc.Kind != Document {
+			t.Fatalf("Parse(%q) returned %+v", s, doc)
+		}
+		for name, out := range map[string]string{"Text": doc.Text(), "Markdown": doc.Markdown(), "HTML": doc.HTML()} {
+			if utf8.ValidString(s) && !utf8.ValidString(out) {
+				t.Errorf("%s(%q) is not valid utf-8: %q", name, s, out)
+			}
+		}
+		b, err := doc.JSON()
+		if err != nil {
+			t.Fatalf("JSON(%q): %s", s, err)
+		}
+		var back Node
+		if err := json.Unmarshal(b, &back); err != nil {
+			t.Fatalf("JSON(%q) does not read back: %s", s, err)
+		}
+	})
+}
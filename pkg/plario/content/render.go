@@ -0,0 +1,225 @@
+package content
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	newlines = regexp.MustCompile(`\n{3,}`)
+	trailing = regexp.MustCompile(`[ \t]+\n`)
+)
+
+// Markdown renders tree keeping structure: paragraphs, lists, pipe tables,
+// emphasis, images, code, super/subscripts as ^{…}/_{…} and math as $…$
+// ($$…$$ for display math)
+func (n *Node) Markdown() string {
+	r := renderer{markdown: true}
+	return tidy(r.render(n, 0))
+}
+
+// Text renders tree without markup, formulas are left as bare tex
+func (n *Node) Text() string {
+	r := renderer{}
+	return tidy(r.render(n, 0))
+}
+
+func tidy(s string) string {
+	s = trailing.ReplaceAllString(s, "\n")
+	s = newlines.ReplaceAllString(s, "\n\n")
+	return strings.TrimSpace(s)
+}
+
+type renderer struct {
+	markdown bool
+}
+
+func block(s string) string {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return ""
+	}
+	return "\n\n" + s + "\n\n"
+}
+
+func (r renderer) mark(s, marker string) string {
+	s = strings.TrimSpace(s)
+	if s == "" || !r.markdown {
+		return s
+	}
+	return marker + s + marker
+}
+
+func (r renderer) children(n *Node, depth int) string {
+	var b strings.Builder
+	for _, c := range n.Children {
+		b.WriteString(r.render(c, depth))
+	}
+	return b.String()
+}
+
+func (r renderer) render(n *Node, depth int) string {
+	switch n.Kind {
+	case Text:
+		return n.Value
+	case Break:
+		return "\n"
+	case Paragraph, Document:
+		return block(r.children(n, depth))
+	case Heading:
+		text := strings.TrimSpace(r.children(n, depth))
+		if r.markdown {
+			text = strings.Repeat("#", max(n.Level, 1)) + " " + text
+		}
+		return block(text)
+	case Strong:
+		return r.mark(r.children(n, depth), "**")
+	case Emphasis:
+		return r.mark(r.children(n, depth), "*")
+	case Sup:
+		return script("^", strings.TrimSpace(r.children(n, depth)), r.markdown)
+	case Sub:
+		return script("_", strings.TrimSpace(r.children(n, depth)), r.markdown)
+	case Formula:
+		switch {
+		case !r.markdown && n.Display:
+			return block(n.Value)
+		case !r.markdown:
+			return n.Value
+		case n.Display:
+			return block("$$" + n.Value + "$$")
+		}
+		return "$" + n.Value + "$"
+	case Code:
+		if n.Display {
+			if r.markdown {
+				return block("```\n" + n.Value + "\n```")
+			}
+			return block(n.Value)
+		}
+		return r.mark(n.Value, "`")
+	case Image:
+		if r.markdown {
+			return "![" + n.Alt + "](" + n.Src + ")"
+		}
+		if n.Alt != "" {
+			return "[image: " + n.Alt + "]"
+		}
+		return "[image]"
+	case Link:
+		text := strings.TrimSpace(r.children(n, depth))
+		if r.markdown && n.Href != "" && n.Href != text {
+			return "[" + text + "](" + n.Href + ")"
+		}
+		return text
+	case Quote:
+		lines := strings.Split(strings.TrimSpace(r.children(n, depth)), "\n")
+		if r.markdown {
+			for i, l := range lines {
+				lines[i] = strings.TrimSpace("> " + l)
+			}
+		}
+		return block(strings.Join(lines, "\n"))
+	case List:
+		return r.list(n, depth)
+	case Item:
+		return r.children(n, depth)
+	case Table:
+		return block(r.table(n, depth))
+	case Row, Cell:
+		return r.children(n, depth)
+	}
+	return r.children(n, depth)
+}
+
+// script renders super/subscript, plain text drops braces around single runes
+func script(marker, s string, markdown bool) string {
+	switch {
+	case s == "":
+		return ""
+	case markdown:
+		return marker + "{" + s + "}"
+	case len([]rune(s)) == 1:
+		return marker + s
+	}
+	return marker + "(" + s + ")"
+}
+
+func (r renderer) list(n *Node, depth int) string {
+	var b strings.Builder
+	indent := strings.Repeat("  ", depth)
+	for i, item := range n.Children {
+		marker := "- "
+		if n.Ordered {
+			marker = strconv.Itoa(i+1) + ". "
+		}
+
+		text := strings.TrimSpace(newlines.ReplaceAllString(r.render(item, depth+1), "\n"))
+		text = strings.ReplaceAll(text, "\n\n", "\n")
+		b.WriteString(indent + marker + text + "\n")
+	}
+
+	if depth > 0 {
+		return "\n" + b.String()
+	}
+	return block(b.String())
+}
+
+func (r renderer) table(n *Node, depth int) string {
+	var rows [][]string
+	header := false
+	width := 0
+
+	for i, row := range n.Children {
+		if i == 0 && row.Header {
+			header = true
+		}
+		var cells []string
+		for _, cell := range row.Children {
+			text := strings.TrimSpace(spaces.ReplaceAllString(r.render(cell, depth), " "))
+			if r.markdown {
+				text = strings.ReplaceAll(text, "|", `\|`)
+			}
+			cells = append(cells, text)
+		}
+		width = max(width, len(cells))
+		rows = append(rows, cells)
+	}
+
+	if len(rows) == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+	writeRow := func(cells []string) {
+		for len(cells) < width {
+			cells = append(cells, "")
+		}
+		if r.markdown {
+			b.WriteString("| " + strings.Join(cells, " | ") + " |\n")
+			return
+		}
+		b.WriteString(strings.Join(cells, "\t") + "\n")
+	}
+
+	if !r.markdown {
+		for _, cells := range rows {
+			writeRow(cells)
+		}
+		return b.String()
+	}
+
+	// markdown tables need a header, empty one is used when html has none
+	if header {
+		writeRow(rows[0])
+		rows = rows[1:]
+	} else {
+		writeRow(nil)
+	}
+	b.WriteString("|" + strings.Repeat(" --- |", width) + "\n")
+	for _, cells := range rows {
+		writeRow(cells)
+	}
+	return b.String()
+}
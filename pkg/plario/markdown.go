@@ -0,0 +1,31 @@
+package plario
+
+import (
+	"pkg/plario/content"
+	"pkg/textsim"
+)
+
+// HTMLToMarkdown converts exercise html into markdown keeping structure:
+// paragraphs, lists, tables, emphasis, images, code, super/subscripts as
+// ^{…}/_{…} and math normalized to $…$ ($$…$$ for display math)
+func HTMLToMarkdown(s string) string {
+	return content.Parse(s).Markdown()
+}
+
+func (e *Exercise) Document() *content.Node {
+	return content.Parse(e.Content)
+}
+
+func (a *PossibleAnswer) Document() *content.Node {
+	return content.Parse(a.Text)
+}
+
+// Fingerprint identifies exercise or answer html by its text, it survives
+// markup, spacing and latex formatting changes
+func Fingerprint(s string) string {
+	return textsim.Fingerprint(content.Parse(s).Text())
+}
+
+func (e *Exercise) Fingerprint() string {
+	return Fingerprint(e.Content)
+}
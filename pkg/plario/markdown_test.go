@@ -0,0 +1,31 @@
+package plario
+
+import "testing"
+
+func TestHTMLToMarkdown(t *testing.T) {
+	tests := []struct {
+		name string
+		html string
+		want string
+	}{
+		{"inline math", `<p>Найдите <span class="math-tex">\(x^2\)</span></p>`, "Найдите $x^2$"},
+		{"display math", `<p>Вычислите \[\frac{1}{2}\]</p>`, "Вычислите\n\n$$\\frac{1}{2}$$"},
+		{"emphasis", `<p><b>жирный</b> и <i>курсив</i></p>`, "**жирный** и *курсив*"},
+		{"scripts", `<p>a<sup>2</sup> + b<sub>i</sub></p>`, "a^{2} + b_{i}"},
+		{"unordered list", `<ul><li>один</li><li>два</li></ul>`, "- один\n- два"},
+		{"ordered list", `<ol><li>один</li><li>два</li></ol>`, "1. один\n2. два"},
+		{
+			"table",
+			`<table><tr><th>x</th><th>y</th></tr><tr><td>1</td><td>2</td></tr></table>`,
+			"| x | y |\n| --- | --- |\n| 1 | 2 |",
+		},
+		{"paragraphs", `<p>один</p><p>два</p>`, "один\n\nдва"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := HTMLToMarkdown(tt.html); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
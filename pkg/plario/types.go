@@ -1,10 +1,8 @@
 package plario
 
 import (
-	"bytes"
-	"encoding/json"
-
-	"golang.org/x/net/html"
+	"fmt"
+	"strings"
 )
 
 type Exercise struct {
@@ -13,42 +11,68 @@ type Exercise struct {
 	PossibleAnswers []PossibleAnswer `json:"possibleAnswers"`
 }
 
-func (e *Exercise) ToString() string {
-	type Answer struct {
-		ID     int    `json:"id"`
-		Option string `json:"answer"`
+const (
+	KindTheory = "theory"
+	KindChoice = "choice"
+)
+
+// Kind tells whether exercise is a theory lesson or a question with options
+func (e *Exercise) Kind() string {
+	if len(e.PossibleAnswers) == 0 {
+		return KindTheory
 	}
-	type Quiz struct {
-		Question string   `json:"question"`
-		Answers  []Answer `json:"answers"`
+	return KindChoice
+}
+
+// Quiz is what the model receives
+type Quiz struct {
+	Question string       `json:"question"`
+	Answers  []QuizAnswer `json:"answers"`
+}
+
+type QuizAnswer struct {
+	ID     int    `json:"id"`
+	Option string `json:"answer"`
+}
+
+// AnswerText returns option text of answer id, empty if it is not offered
+func (q Quiz) AnswerText(id int) string {
+	for _, a := range q.Answers {
+		if a.ID == id {
+			return a.Option
+		}
 	}
+	return ""
+}
 
+func (e *Exercise) Quiz() Quiz {
 	var quiz Quiz
-	quiz.Question = StripHTMLKeepLatex(e.Content)
+	quiz.Question = HTMLToMarkdown(e.Content)
 	for _, i := range e.PossibleAnswers {
-		quiz.Answers = append(quiz.Answers, Answer{ID: i.AnswerID, Option: StripHTMLKeepLatex(i.Text)})
+		quiz.Answers = append(quiz.Answers, QuizAnswer{ID: i.AnswerID, Option: HTMLToMarkdown(i.Text)})
 	}
-	s, _ := json.Marshal(quiz)
-	return string(s)
+	return quiz
 }
 
-// func (e *Exercise) ToString() string {
-// 	s := fmt.Sprintf("Question: %s. Possible answers: ", StripHTMLKeepLatex(e.Content))
-// 	if len(e.PossibleAnswers) > 0 {
-// 		for i, a := range e.PossibleAnswers {
-// 			if i == 0 {
-// 				s += "["
-// 			}
-// 			text := StripHTMLKeepLatex(a.Text)
-// 			s += fmt.Sprintf("text: %s id: %d,", text, a.AnswerID)
-// 		}
-// 		s += "]"
-// 	} else {
-// 		s += "[]"
-// 	}
-//
-// 	return s
-// }
+// Display renders exercise for a human, options are numbered by their ids,
+// with unicode latex is replaced by unicode symbols where possible
+func (e *Exercise) Display(unicode bool) string {
+	quiz := e.Quiz()
+	render := func(s string) string {
+		if unicode {
+			return LatexToUnicode(s)
+		}
+		return s
+	}
+
+	var b strings.Builder
+	b.WriteString(render(quiz.Question))
+	b.WriteString("\n")
+	for _, a := range quiz.Answers {
+		fmt.Fprintf(&b, "\n  [%d] %s", a.ID, strings.ReplaceAll(render(a.Option), "\n", "\n      "))
+	}
+	return b.String()
+}
 
 type PossibleAnswer struct {
 	AnswerID  int    `json:"answerId"`
@@ -90,18 +114,3 @@ type Subject struct {
 	Name    string   `json:"name"`
 	Courses []Course `json:"courses"`
 }
-
-func StripHTMLKeepLatex(s string) string {
-	var b bytes.Buffer
-	z := html.NewTokenizer(bytes.NewBufferString(s))
-
-	for {
-		tt := z.Next()
-		switch tt {
-		case html.ErrorToken:
-			return b.String()
-		case html.TextToken:
-			b.Write(z.Text())
-		}
-	}
-}
@@ -0,0 +1,250 @@
+package plario
+
+import (
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+var latexSymbols = map[string]string{
+	`\alpha`: "α", `\beta`: "β", `\gamma`: "γ", `\delta`: "δ", `\epsilon`: "ε", `\varepsilon`: "ε",
+	`\zeta`: "ζ", `\eta`: "η", `\theta`: "θ", `\vartheta`: "ϑ", `\iota`: "ι", `\kappa`: "κ",
+	`\lambda`: "λ", `\mu`: "μ", `\nu`: "ν", `\xi`: "ξ", `\pi`: "π", `\rho`: "ρ", `\sigma`: "σ",
+	`\tau`: "τ", `\upsilon`: "υ", `\phi`: "φ", `\varphi`: "φ", `\chi`: "χ", `\psi`: "ψ", `\omega`: "ω",
+	`\Gamma`: "Γ", `\Delta`: "Δ", `\Theta`: "Θ", `\Lambda`: "Λ", `\Xi`: "Ξ", `\Pi`: "Π",
+	`\Sigma`: "Σ", `\Phi`: "Φ", `\Psi`: "Ψ", `\Omega`: "Ω",
+
+	`\cdot`: "·", `\times`: "×", `\div`: "÷", `\pm`: "±", `\mp`: "∓", `\ast`: "∗",
+	`\leq`: "≤", `\le`: "≤", `\geq`: "≥", `\ge`: "≥", `\neq`: "≠", `\ne`: "≠",
+	`\approx`: "≈", `\equiv`: "≡", `\sim`: "∼", `\propto`: "∝",
+	`\infty`: "∞", `\to`: "→", `\rightarrow`: "→", `\leftarrow`: "←", `\Rightarrow`: "⇒",
+	`\Leftarrow`: "⇐", `\Leftrightarrow`: "⇔", `\iff`: "⇔", `\implies`: "⇒",
+	`\in`: "∈", `\notin`: "∉", `\subset`: "⊂", `\subseteq`: "⊆", `\supset`: "⊃",
+	`\cup`: "∪", `\cap`: "∩", `\emptyset`: "∅", `\varnothing`: "∅", `\forall`: "∀", `\exists`: "∃",
+	`\neg`: "¬", `\land`: "∧", `\wedge`: "∧", `\lor`: "∨", `\vee`: "∨",
+	`\sum`: "∑", `\prod`: "∏", `\int`: "∫", `\oint`: "∮", `\partial`: "∂", `\nabla`: "∇",
+	`\angle`: "∠", `\degree`: "°", `\circ`: "∘", `\perp`: "⊥", `\parallel`: "∥",
+	`\ldots`: "…", `\dots`: "…", `\cdots`: "⋯", `\prime`: "′",
+	`\mathbb{R}`: "ℝ", `\mathbb{N}`: "ℕ", `\mathbb{Z}`: "ℤ", `\mathbb{Q}`: "ℚ", `\mathbb{C}`: "ℂ",
+	`\,`: " ", `\;`: " ", `\:`: " ", `\!`: "", `\quad`: " ", `\qquad`: "  ", `\ `: " ",
+	`\%`: "%", `\left`: "", `\right`: "", `\displaystyle`: "",
+}
+
+var superscripts = map[rune]rune{
+	'0': '⁰', '1': '¹', '2': '²', '3': '³', '4': '⁴', '5': '⁵', '6': '⁶', '7': '⁷', '8': '⁸', '9': '⁹',
+	'+': '⁺', '-': '⁻', '=': '⁼', '(': '⁽', ')': '⁾', 'n': 'ⁿ', 'i': 'ⁱ', 'x': 'ˣ', 'y': 'ʸ',
+	'a': 'ᵃ', 'b': 'ᵇ', 'c': 'ᶜ', 'd': 'ᵈ', 'e': 'ᵉ', 'k': 'ᵏ', 'm': 'ᵐ', 'o': 'ᵒ', 't': 'ᵗ',
+	'∘': '°', '°': '°', '′': '′',
+}
+
+var subscripts = map[rune]rune{
+	'0': '₀', '1': '₁', '2': '₂', '3': '₃', '4': '₄', '5': '₅', '6': '₆', '7': '₇', '8': '₈', '9': '₉',
+	'+': '₊', '-': '₋', '=': '₌', '(': '₍', ')': '₎', 'a': 'ₐ', 'e': 'ₑ', 'i': 'ᵢ', 'j': 'ⱼ',
+	'k': 'ₖ', 'm': 'ₘ', 'n': 'ₙ', 'o': 'ₒ', 'x': 'ₓ', 't': 'ₜ',
+}
+
+var (
+	latexCommand = regexp.MustCompile(`\\[a-zA-Z]+|\\[,;:! %]`)
+	// \text{…}, \mathrm{…} and alike only change font
+	fontCommand = regexp.MustCompile(`\\(?:text|mathrm|mathit|mathbf|operatorname|textbf|textit)\s*\{([^{}]*)\}`)
+	mathbb      = regexp.MustCompile(`\\mathbb\s*\{([A-Z])\}`)
+)
+
+// LatexToUnicode renders markdown produced by HTMLToMarkdown for a terminal:
+// math delimiters are dropped, common latex commands, fractions, roots and
+// super/subscripts are replaced with unicode
+func LatexToUnicode(s string) string {
+	// escaped braces survive grouping braces removal
+	s = strings.NewReplacer(`\{`, "\uE000", `\}`, "\uE001", "$$", "", "$", "").Replace(s)
+
+	s = mathbb.ReplaceAllStringFunc(s, func(m string) string {
+		if sym, ok := latexSymbols[strings.ReplaceAll(m, " ", "")]; ok {
+			return sym
+		}
+		return m
+	})
+	s = fontCommand.ReplaceAllString(s, "$1")
+	s = replaceCommand(s, `\frac`, 2, func(args []string) string {
+		return group(args[0]) + "/" + group(args[1])
+	})
+	s = replaceCommand(s, `\dfrac`, 2, func(args []string) string {
+		return group(args[0]) + "/" + group(args[1])
+	})
+	s = roots(s)
+	s = replaceCommand(s, `\sqrt`, 1, func(args []string) string {
+		return "√" + group(args[0])
+	})
+
+	s = latexCommand.ReplaceAllStringFunc(s, func(cmd string) string {
+		if sym, ok := latexSymbols[cmd]; ok {
+			return sym
+		}
+		// unknown commands like \sin or \log read fine without the backslash
+		return strings.TrimPrefix(cmd, `\`)
+	})
+
+	s = scripts(s, '^', superscripts)
+	s = scripts(s, '_', subscripts)
+
+	return strings.NewReplacer("{", "", "}", "", "\uE000", "{", "\uE001", "}").Replace(s)
+}
+
+// roots replaces \sqrt[n]{…} with ∛, ∜ or a superscript index before √, an
+// index without superscript form stays in brackets
+func roots(s string) string {
+	for {
+		i := strings.Index(s, `\sqrt[`)
+		if i < 0 {
+			return s
+		}
+
+		rest := s[i+len(`\sqrt[`):]
+		end := strings.IndexByte(rest, ']')
+		if end < 0 {
+			return s
+		}
+		arg, next, ok := braceGroup(rest, end+1)
+		if !ok {
+			return s
+		}
+
+		index := strings.TrimSpace(rest[:end])
+		sign := "[" + index + "]√"
+		switch sup, all := convertRunes(index, superscripts); {
+		case index == "3":
+			sign = "∛"
+		case index == "4":
+			sign = "∜"
+		case all:
+			sign = sup + "√"
+		}
+		s = s[:i] + sign + group(arg) + rest[next:]
+	}
+}
+
+// group wraps s in parentheses unless it is a single token
+func group(s string) string {
+	s = strings.TrimSpace(s)
+	if utf8.RuneCountInString(s) <= 1 || isWord(s) {
+		return s
+	}
+	return "(" + s + ")"
+}
+
+func isWord(s string) bool {
+	for _, r := range s {
+		if !(r >= '0' && r <= '9' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r == '.') {
+			return false
+		}
+	}
+	return true
+}
+
+// replaceCommand replaces cmd{a}{b}… taking n brace groups, nested braces allowed
+func replaceCommand(s, cmd string, n int, render func(args []string) string) string {
+	for {
+		i := strings.Index(s, cmd)
+		if i < 0 {
+			return s
+		}
+
+		rest := s[i+len(cmd):]
+		// \frac must not match \fraction
+		if len(rest) > 0 && (rest[0] >= 'a' && rest[0] <= 'z' || rest[0] >= 'A' && rest[0] <= 'Z') {
+			return s[:i+len(cmd)] + replaceCommand(rest, cmd, n, render)
+		}
+
+		args := make([]string, 0, n)
+		pos := 0
+		for len(args) < n {
+			arg, next, ok := braceGroup(rest, pos)
+			if !ok {
+				break
+			}
+			args = append(args, arg)
+			pos = next
+		}
+		if len(args) < n {
+			return s[:i+len(cmd)] + replaceCommand(rest, cmd, n, render)
+		}
+
+		s = s[:i] + render(args) + rest[pos:]
+	}
+}
+
+// braceGroup reads {…} or a single character starting at pos, skipping spaces
+func braceGroup(s string, pos int) (string, int, bool) {
+	for pos < len(s) && s[pos] == ' ' {
+		pos++
+	}
+	if pos >= len(s) {
+		return "", pos, false
+	}
+	if s[pos] != '{' {
+		_, size := utf8.DecodeRuneInString(s[pos:])
+		return s[pos : pos+size], pos + size, true
+	}
+
+	depth := 0
+	for i := pos; i < len(s); i++ {
+		switch s[i] {
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				return s[pos+1 : i], i + 1, true
+			}
+		}
+	}
+	return "", pos, false
+}
+
+// scripts converts ^{…}/^x (or _) when every rune has a unicode form,
+// otherwise leaves ^(…)
+func scripts(s string, marker byte, table map[rune]rune) string {
+	var b strings.Builder
+	for i := 0; i < len(s); {
+		if s[i] != marker {
+			b.WriteByte(s[i])
+			i++
+			continue
+		}
+
+		arg, next, ok := braceGroup(s, i+1)
+		if !ok {
+			b.WriteByte(s[i])
+			i++
+			continue
+		}
+
+		converted, all := convertRunes(strings.TrimSpace(arg), table)
+		switch {
+		case all:
+			b.WriteString(converted)
+		case utf8.RuneCountInString(arg) == 1:
+			b.WriteByte(marker)
+			b.WriteString(arg)
+		default:
+			b.WriteByte(marker)
+			b.WriteString("(" + arg + ")")
+		}
+		i = next
+	}
+	return b.String()
+}
+
+func convertRunes(s string, table map[rune]rune) (string, bool) {
+	if s == "" {
+		return "", false
+	}
+	var b strings.Builder
+	for _, r := range s {
+		c, ok := table[r]
+		if !ok {
+			return "", false
+		}
+		b.WriteRune(c)
+	}
+	return b.String(), true
+}
@@ -0,0 +1,43 @@
+package plario
+
+import "testing"
+
+func TestLatexToUnicode(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"symbols", `$\alpha \leq \beta$`, "α ≤ β"},
+		{"display math", `$$x \neq 0$$`, "x ≠ 0"},
+		{"dollar outside math", `цена 5$`, "цена 5"},
+		{"frac", `$\frac{1}{2}$`, "1/2"},
+		{"frac groups", `$\frac{a+b}{c}$`, "(a+b)/c"},
+		{"dfrac", `$\dfrac{x}{y+1}$`, "x/(y+1)"},
+		{"not frac", `$\fraction$`, "fraction"},
+		{"sqrt", `$\sqrt{x+1}$`, "√(x+1)"},
+		{"cube root", `$\sqrt[3]{x}$`, "∛x"},
+		{"fourth root", `$\sqrt[4]{x+1}$`, "∜(x+1)"},
+		{"root index", `$\sqrt[n]{x}$`, "ⁿ√x"},
+		{"root index without superscript", `$\sqrt[q]{x}$`, "[q]√x"},
+		{"superscript", `$x^{10}$`, "x¹⁰"},
+		{"bare superscript", `$x^2$`, "x²"},
+		{"signed superscript", `$e^{-x}$`, "e⁻ˣ"},
+		{"superscript fallback", `$x^{q}$`, "x^q"},
+		{"long superscript fallback", `$x^{2q}$`, "x^(2q)"},
+		{"subscript", `$a_{i}$`, "aᵢ"},
+		{"subscript fallback", `$a_{b}$`, "a_b"},
+		{"long subscript fallback", `$a_{xy}$`, "a_(xy)"},
+		{"escaped braces", `$\{1, 2\}$`, "{1, 2}"},
+		{"font", `$5\text{ км}$`, "5 км"},
+		{"blackboard", `$x \in \mathbb{R}$`, "x ∈ ℝ"},
+		{"unknown command", `$\sin x$`, "sin x"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := LatexToUnicode(tt.in); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
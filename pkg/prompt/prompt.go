@@ -0,0 +1,146 @@
+package prompt
+
+import (
+	"bytes"
+	_ "embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+)
+
+//go:embed default.tmpl
+var defaultTemplate string
+
+const DefaultName = "default.tmpl"
+
+type Named struct {
+	ID   int
+	Name string
+}
+
+// Data is what prompt templates are executed with
+type Data struct {
+	Subject Named
+	Course  Named
+	Module  Named
+
+	// Kind is a question kind, see plario.Exercise.Kind
+	Kind    string
+	Culture string
+
+	// Reasoning is set when the model is allowed to think before answering,
+	// the answer is then expected on a last line "ANSWER: <id>"
+	Reasoning bool
+}
+
+var languages = map[string]string{
+	"ru": "russian",
+	"en": "english",
+}
+
+// Language names culture for prompts, "ru" is "russian"
+func Language(culture string) string {
+	if l, ok := languages[culture]; ok {
+		return l
+	}
+	return culture
+}
+
+var funcs = template.FuncMap{
+	"language": Language,
+	"lower":    strings.ToLower,
+	"upper":    strings.ToUpper,
+}
+
+// Resolver picks the most specific template from Dir:
+// module_<id>.tmpl > course_<id>.tmpl > subject_<id>.tmpl > default.tmpl,
+// falling back to embedded default when nothing matches
+type Resolver struct {
+	Dir string
+
+	cache map[string]*template.Template
+}
+
+func NewResolver(dir string) *Resolver {
+	return &Resolver{Dir: dir, cache: make(map[string]*template.Template)}
+}
+
+// Candidates returns file names checked for d, most specific first
+func Candidates(d Data) []string {
+	var names []string
+	if d.Module.ID != 0 {
+		names = append(names, fmt.Sprintf("module_%d.tmpl", d.Module.ID))
+	}
+	if d.Course.ID != 0 {
+		names = append(names, fmt.Sprintf("course_%d.tmpl", d.Course.ID))
+	}
+	if d.Subject.ID != 0 {
+		names = append(names, fmt.Sprintf("subject_%d.tmpl", d.Subject.ID))
+	}
+	return append(names, DefaultName)
+}
+
+// Resolve returns template for d and the path it was loaded from,
+// "embedded" for builtin default
+func (r *Resolver) Resolve(d Data) (*template.Template, string, error) {
+	if r.Dir != "" {
+		for _, name := range Candidates(d) {
+			path := filepath.Join(r.Dir, name)
+			t, err := r.load(path)
+			if errors.Is(err, fs.ErrNotExist) {
+				continue
+			}
+			if err != nil {
+				return nil, path, err
+			}
+			return t, path, nil
+		}
+	}
+
+	t, ok := r.cache["embedded"]
+	if !ok {
+		var err error
+		t, err = template.New(DefaultName).Funcs(funcs).Parse(defaultTemplate)
+		if err != nil {
+			return nil, "embedded", err
+		}
+		r.cache["embedded"] = t
+	}
+	return t, "embedded", nil
+}
+
+func (r *Resolver) load(path string) (*template.Template, error) {
+	if t, ok := r.cache[path]; ok {
+		return t, nil
+	}
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	t, err := template.New(filepath.Base(path)).Funcs(funcs).Option("missingkey=error").Parse(string(b))
+	if err != nil {
+		return nil, fmt.Errorf("prompt.load %s: %s", path, err)
+	}
+	r.cache[path] = t
+	return t, nil
+}
+
+// Render resolves and executes template for d
+func (r *Resolver) Render(d Data) (string, error) {
+	t, path, err := r.Resolve(d)
+	if err != nil {
+		return "", err
+	}
+
+	var b bytes.Buffer
+	if err := t.Execute(&b, d); err != nil {
+		return "", fmt.Errorf("prompt.Render %s: %s", path, err)
+	}
+	return strings.TrimSpace(b.String()), nil
+}
@@ -0,0 +1,103 @@
+package prompt
+
+import (
+	"maps"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var testData = Data{
+	Subject: Named{ID: 1, Name: "Математика"},
+	Course:  Named{ID: 2, Name: "Алгебра"},
+	Module:  Named{ID: 3, Name: "Сложение"},
+	Kind:    "choice",
+	Culture: "ru",
+}
+
+func writeTemplates(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, text := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(text), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestResolveOrder(t *testing.T) {
+	module := map[string]string{"module_3.tmpl": "module {{.Module.Name}}"}
+	course := map[string]string{"course_2.tmpl": "course {{.Course.Name}}"}
+	subject := map[string]string{"subject_1.tmpl": "subject {{.Subject.Name}}"}
+	def := map[string]string{DefaultName: "default {{language .Culture}}"}
+	other := map[string]string{"module_4.tmpl": "module 4"}
+
+	tests := []struct {
+		name  string
+		files []map[string]string
+		want  string
+		out   string
+	}{
+		{"module", []map[string]string{module, course, subject, def}, "module_3.tmpl", "module Сложение"},
+		{"course", []map[string]string{course, subject, def}, "course_2.tmpl", "course Алгебра"},
+		{"subject", []map[string]string{subject, def}, "subject_1.tmpl", "subject Математика"},
+		{"default", []map[string]string{def}, DefaultName, "default russian"},
+		{"other module", []map[string]string{other, def}, DefaultName, "default russian"},
+		{"embedded", []map[string]string{other}, "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			files := make(map[string]string)
+			for _, f := range tt.files {
+				maps.Copy(files, f)
+			}
+			r := NewResolver(writeTemplates(t, files))
+
+			_, path, err := r.Resolve(testData)
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := "embedded"
+			if tt.want != "" {
+				want = filepath.Join(r.Dir, tt.want)
+			}
+			if path != want {
+				t.Errorf("path %q, want %q", path, want)
+			}
+
+			out, err := r.Render(testData)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.out != "" && out != tt.out {
+				t.Errorf("rendered %q, want %q", out, tt.out)
+			}
+		})
+	}
+}
+
+func TestResolveWithoutDir(t *testing.T) {
+	_, path, err := NewResolver("").Resolve(testData)
+	if err != nil || path != "embedded" {
+		t.Errorf("path %q %v, want embedded", path, err)
+	}
+}
+
+// templates from files fail on a field Data does not have instead of
+// rendering <no value>
+func TestRenderMissingKey(t *testing.T) {
+	r := NewResolver(writeTemplates(t, map[string]string{DefaultName: "{{.Lesson}}"}))
+	_, err := r.Render(testData)
+	if err == nil || !strings.Contains(err.Error(), DefaultName) {
+		t.Errorf("error %v, want one naming %s", err, DefaultName)
+	}
+}
+
+func TestResolveParseError(t *testing.T) {
+	r := NewResolver(writeTemplates(t, map[string]string{"module_3.tmpl": "{{.Module.Name"}))
+	if _, path, err := r.Resolve(testData); err == nil || filepath.Base(path) != "module_3.tmpl" {
+		t.Errorf("path %q, error %v", path, err)
+	}
+}
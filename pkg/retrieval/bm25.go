@@ -0,0 +1,65 @@
+package retrieval
+
+import (
+	"math"
+	"pkg/textsim"
+)
+
+// BM25 is an Okapi BM25 ranking over a fixed set of documents,
+// used when no embeddings endpoint is configured
+type BM25 struct {
+	K1 float64
+	B  float64
+
+	docs  []map[string]int
+	lens  []int
+	df    map[string]int
+	avgdl float64
+}
+
+func NewBM25(docs []string) *BM25 {
+	b := &BM25{K1: 1.2, B: 0.75, df: make(map[string]int)}
+
+	var total int
+	for _, d := range docs {
+		tokens := textsim.Tokens(d)
+		tf := make(map[string]int, len(tokens))
+		for _, t := range tokens {
+			tf[t]++
+		}
+		for t := range tf {
+			b.df[t]++
+		}
+		b.docs = append(b.docs, tf)
+		b.lens = append(b.lens, len(tokens))
+		total += len(tokens)
+	}
+	if len(docs) > 0 {
+		b.avgdl = float64(total) / float64(len(docs))
+	}
+	return b
+}
+
+// Scores returns score of every document for query, in documents order
+func (b *BM25) Scores(query string) []float64 {
+	scores := make([]float64, len(b.docs))
+	n := float64(len(b.docs))
+
+	for _, t := range textsim.Tokens(query) {
+		df, ok := b.df[t]
+		if !ok {
+			continue
+		}
+		idf := math.Log(1 + (n-float64(df)+0.5)/(float64(df)+0.5))
+
+		for i, tf := range b.docs {
+			f := float64(tf[t])
+			if f == 0 {
+				continue
+			}
+			norm := 1 - b.B + b.B*float64(b.lens[i])/b.avgdl
+			scores[i] += idf * f * (b.K1 + 1) / (f + b.K1*norm)
+		}
+	}
+	return scores
+}
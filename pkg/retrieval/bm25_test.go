@@ -0,0 +1,43 @@
+package retrieval
+
+import "testing"
+
+func TestBM25Scores(t *testing.T) {
+	docs := []string{
+		"производная степенной функции равна показателю умноженному на степень на единицу меньше",
+		"логарифм произведения равен сумме логарифмов",
+		"площадь круга равна пи эр квадрат",
+	}
+	b := NewBM25(docs)
+
+	tests := []struct {
+		query string
+		best  int
+	}{
+		{"логарифм произведения", 1},
+		{"производная степенной функции", 0},
+		{"площадь круга", 2},
+	}
+	for _, tt := range tests {
+		scores := b.Scores(tt.query)
+		if len(scores) != len(docs) {
+			t.Fatalf("%q: %d scores for %d docs", tt.query, len(scores), len(docs))
+		}
+		for i, s := range scores {
+			if i != tt.best && s >= scores[tt.best] {
+				t.Errorf("%q: doc %d scored %f, best %d scored %f", tt.query, i, s, tt.best, scores[tt.best])
+			}
+		}
+	}
+}
+
+func TestBM25UnknownTerms(t *testing.T) {
+	for _, s := range NewBM25([]string{"a b c"}).Scores("x y") {
+		if s != 0 {
+			t.Errorf("score of unknown terms is %f", s)
+		}
+	}
+	if scores := NewBM25(nil).Scores("x"); len(scores) != 0 {
+		t.Errorf("scores without docs: %v", scores)
+	}
+}
@@ -0,0 +1,42 @@
+package retrieval
+
+import "strings"
+
+// Chunk splits text into pieces of about size words where consecutive
+// pieces share overlap words. A piece is cut at the first sentence end after
+// size words, or hard at one and a half size when there is none.
+func Chunk(text string, size, overlap int) []string {
+	words := strings.Fields(text)
+	if len(words) == 0 {
+		return nil
+	}
+	if size <= 0 || len(words) <= size {
+		return []string{strings.Join(words, " ")}
+	}
+	if overlap < 0 || overlap >= size {
+		overlap = 0
+	}
+
+	var chunks []string
+	start := 0
+	for start < len(words) {
+		end := start
+		for end < len(words) {
+			end++
+			n := end - start
+			if n >= size && sentenceEnd(words[end-1]) || n >= size+size/2 {
+				break
+			}
+		}
+		chunks = append(chunks, strings.Join(words[start:end], " "))
+		if end == len(words) {
+			break
+		}
+		start = end - overlap
+	}
+	return chunks
+}
+
+func sentenceEnd(word string) bool {
+	return strings.HasSuffix(word, ".") || strings.HasSuffix(word, "!") || strings.HasSuffix(word, "?")
+}
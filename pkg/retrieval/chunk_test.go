@@ -0,0 +1,43 @@
+package retrieval
+
+import (
+	"strings"
+	"testing"
+)
+
+func words(n int, last string) string {
+	w := make([]string, n)
+	for i := range w {
+		w[i] = "w"
+	}
+	w[n-1] += last
+	return strings.Join(w, " ")
+}
+
+func TestChunk(t *testing.T) {
+	tests := []struct {
+		name          string
+		text          string
+		size, overlap int
+		want          []int
+	}{
+		{"empty", "  ", 10, 2, nil},
+		{"short", words(5, "."), 10, 2, []int{5}},
+		{"sentence end", words(12, ".") + " " + words(8, "."), 10, 2, []int{12, 10}},
+		{"hard cut", words(40, ""), 10, 0, []int{15, 15, 10}},
+		{"overlap", words(30, ""), 10, 5, []int{15, 15, 10}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Chunk(tt.text, tt.size, tt.overlap)
+			if len(got) != len(tt.want) {
+				t.Fatalf("%d chunks, want %d: %q", len(got), len(tt.want), got)
+			}
+			for i, c := range got {
+				if n := len(strings.Fields(c)); n != tt.want[i] {
+					t.Errorf("chunk %d has %d words, want %d", i, n, tt.want[i])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,110 @@
+package retrieval
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"strings"
+)
+
+type Embedder interface {
+	Embed(client *http.Client, texts []string) ([][]float32, error)
+	// Name identifies embeddings, vectors of different names are not comparable
+	Name() string
+}
+
+// OpenAIEmbedder talks to any OpenAI compatible /embeddings endpoint
+type OpenAIEmbedder struct {
+	BaseURL string
+	Model   string
+	Token   string
+}
+
+func NewOpenAIEmbedder(baseURL, model, token string) *OpenAIEmbedder {
+	return &OpenAIEmbedder{
+		BaseURL: strings.TrimSuffix(baseURL, "/"),
+		Model:   model,
+		Token:   token,
+	}
+}
+
+func (e *OpenAIEmbedder) Name() string {
+	return e.Model
+}
+
+func (e *OpenAIEmbedder) Embed(client *http.Client, texts []string) ([][]float32, error) {
+	b, err := json.Marshal(struct {
+		Model string   `json:"model"`
+		Input []string `json:"input"`
+	}{Model: e.Model, Input: texts})
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest("POST", e.BaseURL+"/embeddings", bytes.NewReader(b))
+	if err != nil {
+		return nil, err
+	}
+	if e.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+e.Token)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Embed: bad status code %d: %s", resp.StatusCode, string(body))
+	}
+
+	var er struct {
+		Data []struct {
+			Index     int       `json:"index"`
+			Embedding []float32 `json:"embedding"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(body, &er); err != nil {
+		return nil, fmt.Errorf("err unmarshaling %s", string(body))
+	}
+
+	vectors := make([][]float32, len(texts))
+	for _, d := range er.Data {
+		if d.Index < 0 || d.Index >= len(vectors) {
+			return nil, fmt.Errorf("Embed: index %d out of range", d.Index)
+		}
+		vectors[d.Index] = d.Embedding
+	}
+	for i, v := range vectors {
+		if v == nil {
+			return nil, fmt.Errorf("Embed: no embedding for input %d", i)
+		}
+	}
+	return vectors, nil
+}
+
+func cosine(a, b []float32) float64 {
+	if len(a) != len(b) || len(a) == 0 {
+		return 0
+	}
+
+	var dot, na, nb float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		na += float64(a[i]) * float64(a[i])
+		nb += float64(b[i]) * float64(b[i])
+	}
+	if na == 0 || nb == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(na) * math.Sqrt(nb))
+}
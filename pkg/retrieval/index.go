@@ -0,0 +1,187 @@
+package retrieval
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"pkg/storage"
+	"sort"
+)
+
+// Index keeps theory lesson chunks per module and finds the ones relevant to
+// a question. Chunks are ranked by embeddings cosine when Embedder is set and
+// every chunk of the module has a vector from it, by BM25 otherwise.
+type Index struct {
+	// Embedder is optional, nil means BM25 only
+	Embedder Embedder
+	// Store is optional, without it index lives only for the session
+	Store storage.Store
+
+	ChunkSize int
+	Overlap   int
+
+	modules map[int][]storage.TheoryChunk
+	logger  *slog.Logger
+}
+
+func NewIndex(embedder Embedder, store storage.Store, logger *slog.Logger) *Index {
+	return &Index{
+		Embedder:  embedder,
+		Store:     store,
+		ChunkSize: 120,
+		Overlap:   20,
+		modules:   make(map[int][]storage.TheoryChunk),
+		logger:    logger,
+	}
+}
+
+type Hit struct {
+	Chunk storage.TheoryChunk
+	Score float64
+}
+
+// Add chunks a lesson text, embeds the chunks when possible and stores them
+func (ix *Index) Add(ctx context.Context, client *http.Client, courseID, moduleID, activityID int, text string) error {
+	pieces := Chunk(text, ix.ChunkSize, ix.Overlap)
+
+	chunks := make([]storage.TheoryChunk, len(pieces))
+	for i, p := range pieces {
+		chunks[i] = storage.TheoryChunk{
+			ActivityID: activityID,
+			Position:   i,
+			Content:    p,
+			CourseID:   courseID,
+			ModuleID:   moduleID,
+		}
+	}
+	ix.embed(client, chunks)
+
+	if ix.Store != nil {
+		if err := ix.Store.ReplaceTheoryChunks(ctx, activityID, chunks); err != nil {
+			return err
+		}
+	}
+
+	existing, err := ix.load(ctx, client, moduleID)
+	if err != nil {
+		return err
+	}
+	kept := existing[:0]
+	for _, c := range existing {
+		if c.ActivityID != activityID {
+			kept = append(kept, c)
+		}
+	}
+	ix.modules[moduleID] = append(kept, chunks...)
+	return nil
+}
+
+// Search returns up to k chunks of module with positive score, best first
+func (ix *Index) Search(ctx context.Context, client *http.Client, moduleID int, query string, k int) ([]Hit, error) {
+	chunks, err := ix.load(ctx, client, moduleID)
+	if err != nil || len(chunks) == 0 || k <= 0 {
+		return nil, err
+	}
+
+	scores := ix.semantic(client, chunks, query)
+	if scores == nil {
+		docs := make([]string, len(chunks))
+		for i, c := range chunks {
+			docs[i] = c.Content
+		}
+		scores = NewBM25(docs).Scores(query)
+	}
+
+	var hits []Hit
+	for i, s := range scores {
+		if s > 0 {
+			hits = append(hits, Hit{Chunk: chunks[i], Score: s})
+		}
+	}
+	sort.SliceStable(hits, func(i, j int) bool { return hits[i].Score > hits[j].Score })
+
+	if len(hits) > k {
+		hits = hits[:k]
+	}
+	return hits, nil
+}
+
+// semantic returns nil when embeddings can not be used for chunks
+func (ix *Index) semantic(client *http.Client, chunks []storage.TheoryChunk, query string) []float64 {
+	if ix.Embedder == nil {
+		return nil
+	}
+	for _, c := range chunks {
+		if len(c.Embedding) == 0 || c.EmbeddingModel != ix.Embedder.Name() {
+			return nil
+		}
+	}
+
+	vectors, err := ix.Embedder.Embed(client, []string{query})
+	if err != nil {
+		ix.logger.Warn("retrieval: embedding query, falling back to bm25", "message", err.Error())
+		return nil
+	}
+
+	scores := make([]float64, len(chunks))
+	for i, c := range chunks {
+		scores[i] = cosine(vectors[0], c.Embedding)
+	}
+	return scores
+}
+
+// load reads module chunks from Store once and embeds those stored without
+// vectors of the current embedder
+func (ix *Index) load(ctx context.Context, client *http.Client, moduleID int) ([]storage.TheoryChunk, error) {
+	if chunks, ok := ix.modules[moduleID]; ok || ix.Store == nil {
+		return chunks, nil
+	}
+
+	chunks, err := ix.Store.ListTheoryChunks(ctx, moduleID)
+	if err != nil {
+		return nil, err
+	}
+
+	var stale []storage.TheoryChunk
+	for _, c := range chunks {
+		if ix.Embedder != nil && c.EmbeddingModel != ix.Embedder.Name() {
+			stale = append(stale, c)
+		}
+	}
+	if len(stale) > 0 && ix.embed(client, stale) {
+		if err := ix.Store.CreateTheoryChunks(ctx, stale); err != nil {
+			ix.logger.Warn("retrieval: storing embeddings", "message", err.Error())
+		}
+		chunks, err = ix.Store.ListTheoryChunks(ctx, moduleID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	ix.modules[moduleID] = chunks
+	return chunks, nil
+}
+
+// embed fills chunk embeddings in place, reports whether it succeeded
+func (ix *Index) embed(client *http.Client, chunks []storage.TheoryChunk) bool {
+	if ix.Embedder == nil || len(chunks) == 0 {
+		return false
+	}
+
+	texts := make([]string, len(chunks))
+	for i, c := range chunks {
+		texts[i] = c.Content
+	}
+
+	vectors, err := ix.Embedder.Embed(client, texts)
+	if err != nil {
+		ix.logger.Warn("retrieval: embedding chunks, bm25 will be used", "message", err.Error())
+		return false
+	}
+
+	for i := range chunks {
+		chunks[i].Embedding = vectors[i]
+		chunks[i].EmbeddingModel = ix.Embedder.Name()
+	}
+	return true
+}
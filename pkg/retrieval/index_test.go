@@ -0,0 +1,39 @@
+package retrieval
+
+import (
+	"log/slog"
+	"pkg/storage"
+	"strings"
+	"testing"
+)
+
+func TestIndexReplacesLessonChunks(t *testing.T) {
+	db := storage.NewMemory()
+	ix := NewIndex(nil, db, slog.Default())
+	ix.ChunkSize, ix.Overlap = 5, 0
+
+	long := strings.Repeat("старое содержание урока. ", 10)
+	if err := ix.Add(t.Context(), nil, 1, 2, 3, long); err != nil {
+		t.Fatal(err)
+	}
+	if err := ix.Add(t.Context(), nil, 1, 2, 3, "новое короткое содержание"); err != nil {
+		t.Fatal(err)
+	}
+
+	chunks, err := db.ListTheoryChunks(t.Context(), 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(chunks) != 1 || chunks[0].Content != "новое короткое содержание" {
+		t.Fatalf("stored chunks %+v", chunks)
+	}
+
+	fresh := NewIndex(nil, db, slog.Default())
+	hits, err := fresh.Search(t.Context(), nil, 2, "старое", 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(hits) != 0 {
+		t.Errorf("stale chunks found: %+v", hits)
+	}
+}
@@ -0,0 +1,81 @@
+// Package review schedules bank exercises for offline repetition with the
+// SM-2 algorithm.
+package review
+
+import (
+	"math"
+	"time"
+)
+
+const (
+	DefaultEase = 2.5
+	// MinEase keeps hard cards from being shown every day forever
+	MinEase = 1.3
+)
+
+// answer quality on the SM-2 scale of 0-5, below Hard is a lapse
+const (
+	Again = 1
+	Hard  = 3
+	Good  = 4
+	Easy  = 5
+)
+
+// Card is repetition state of one exercise
+type Card struct {
+	ExerciseID int
+	// Ease is SM-2 easiness factor, the interval grows by it
+	Ease float64
+	// Interval is days between the last review and DueAt
+	Interval int
+	// Repetitions is number of successful reviews in a row
+	Repetitions int
+	// Lapses is number of times the card was forgotten
+	Lapses int
+
+	// DueAt and ReviewedAt are zero for a card never reviewed
+	DueAt      time.Time
+	ReviewedAt time.Time
+}
+
+func NewCard(exerciseID int) Card {
+	return Card{ExerciseID: exerciseID, Ease: DefaultEase}
+}
+
+// New reports whether the card was never reviewed
+func (c Card) New() bool {
+	return c.ReviewedAt.IsZero()
+}
+
+// Next returns the card rescheduled after an answer of quality at now: a
+// lapse starts repetitions over with one day, successes go 1 and 6 days and
+// then multiply the interval by ease. Ease is adjusted on every answer.
+func (c Card) Next(quality int, now time.Time) Card {
+	quality = min(max(quality, 0), 5)
+	if c.Ease == 0 {
+		c.Ease = DefaultEase
+	}
+
+	if quality < Hard {
+		c.Repetitions = 0
+		c.Interval = 1
+		c.Lapses++
+	} else {
+		switch c.Repetitions {
+		case 0:
+			c.Interval = 1
+		case 1:
+			c.Interval = 6
+		default:
+			c.Interval = int(math.Round(float64(c.Interval) * c.Ease))
+		}
+		c.Repetitions++
+	}
+
+	q := float64(5 - quality)
+	c.Ease = max(c.Ease+0.1-q*(0.08+q*0.02), MinEase)
+
+	c.ReviewedAt = now
+	c.DueAt = now.AddDate(0, 0, c.Interval)
+	return c
+}
@@ -0,0 +1,107 @@
+package review
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+var now = time.Date(2026, 10, 1, 9, 0, 0, 0, time.UTC)
+
+func TestNextIntervals(t *testing.T) {
+	c := NewCard(1)
+	if !c.New() {
+		t.Fatal("new card is reviewed")
+	}
+
+	// ease stays 2.5 on Good, so intervals go 1, 6, 6*2.5, 15*2.5 rounded
+	for i, want := range []int{1, 6, 15, 38} {
+		c = c.Next(Good, now)
+		if c.Interval != want || c.Repetitions != i+1 {
+			t.Fatalf("review %d: interval %d, repetitions %d, want %d and %d", i+1, c.Interval, c.Repetitions, want, i+1)
+		}
+		if c.Ease != DefaultEase {
+			t.Errorf("review %d: ease %v, want %v", i+1, c.Ease, DefaultEase)
+		}
+	}
+	if c.New() || !c.ReviewedAt.Equal(now) || !c.DueAt.Equal(now.AddDate(0, 0, 38)) {
+		t.Errorf("reviewed %v, due %v", c.ReviewedAt, c.DueAt)
+	}
+}
+
+func TestNextLapse(t *testing.T) {
+	c := NewCard(1).Next(Good, now).Next(Good, now).Next(Good, now)
+	c = c.Next(Again, now)
+	if c.Interval != 1 || c.Repetitions != 0 || c.Lapses != 1 {
+		t.Fatalf("after lapse interval %d, repetitions %d, lapses %d", c.Interval, c.Repetitions, c.Lapses)
+	}
+	if !c.DueAt.Equal(now.AddDate(0, 0, 1)) {
+		t.Errorf("due %v, want the next day", c.DueAt)
+	}
+
+	// repetitions start over with 1 and 6 days
+	c = c.Next(Good, now)
+	if c.Interval != 1 {
+		t.Errorf("first success after lapse interval %d, want 1", c.Interval)
+	}
+	c = c.Next(Good, now)
+	if c.Interval != 6 || c.Lapses != 1 {
+		t.Errorf("second success after lapse interval %d, lapses %d", c.Interval, c.Lapses)
+	}
+}
+
+func TestNextEase(t *testing.T) {
+	tests := []struct {
+		quality int
+		ease    float64
+	}{
+		{Easy, 2.6},
+		{Good, 2.5},
+		{Hard, 2.36},
+		{Again, 1.96},
+		{0, 1.7},
+	}
+	for _, tt := range tests {
+		c := NewCard(1).Next(tt.quality, now)
+		if math.Abs(c.Ease-tt.ease) > 1e-9 {
+			t.Errorf("quality %d: ease %v, want %v", tt.quality, c.Ease, tt.ease)
+		}
+	}
+}
+
+func TestNextMinEase(t *testing.T) {
+	c := NewCard(1)
+	for range 10 {
+		c = c.Next(Again, now)
+	}
+	if c.Ease != MinEase {
+		t.Errorf("ease %v, want floor %v", c.Ease, MinEase)
+	}
+	if c.Lapses != 10 {
+		t.Errorf("lapses %d, want 10", c.Lapses)
+	}
+}
+
+func TestNextClampsQuality(t *testing.T) {
+	tests := []struct {
+		quality, clamped int
+	}{
+		{-3, 0},
+		{9, 5},
+	}
+	for _, tt := range tests {
+		got := NewCard(1).Next(Good, now).Next(tt.quality, now)
+		want := NewCard(1).Next(Good, now).Next(tt.clamped, now)
+		if got != want {
+			t.Errorf("quality %d: %+v, want as %d: %+v", tt.quality, got, tt.clamped, want)
+		}
+	}
+}
+
+func TestNextZeroEase(t *testing.T) {
+	// cards stored before ease was known start from the default
+	c := Card{ExerciseID: 1}.Next(Good, now).Next(Good, now).Next(Good, now)
+	if c.Ease != DefaultEase || c.Interval != 15 {
+		t.Errorf("ease %v, interval %d, want %v and 15", c.Ease, c.Interval, DefaultEase)
+	}
+}
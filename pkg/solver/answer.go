@@ -0,0 +1,55 @@
+package solver
+
+import (
+	"fmt"
+	"net/http"
+	"pkg/llm"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+var (
+	thinkBlock = regexp.MustCompile(`(?s)<think>.*?</think>`)
+	answerLine = regexp.MustCompile(`(?i)answer\s*[:：]\s*[*(\[]*\s*([A-Z]{1,2}\d*|\d+)\b`)
+)
+
+// extractAnswer maps model output to an offered answer id. The output may be
+// just a label, a label on the last "ANSWER: <label>" line, or a raw answer
+// id which is accepted only if it is one of the offered ids.
+func extractAnswer(content string, l *labeling) (int, error) {
+	content = strings.TrimSpace(thinkBlock.ReplaceAllString(content, ""))
+
+	token := strings.Trim(content, " *.()[]`\"'")
+	if m := answerLine.FindAllStringSubmatch(content, -1); len(m) > 0 {
+		token = m[len(m)-1][1]
+	}
+
+	if id, ok := l.ID(strings.ToUpper(token)); ok {
+		return id, nil
+	}
+	if id, err := strconv.Atoi(token); err == nil {
+		if slices.Contains(l.ids, id) {
+			return id, nil
+		}
+		return 0, fmt.Errorf("%w: %d not in %v", ErrAnswerNotOffered, id, l.ids)
+	}
+	return 0, fmt.Errorf("%w: %q", ErrNoAnswer, content)
+}
+
+// extract asks the model to pull the final answer label out of free form solution
+func (s *Solver) extract(client *http.Client, g *llm.Groq, solution string, l *labeling) (int, error) {
+	resp, err := g.Complete(client, []llm.Message{
+		{Role: "system", Content: "Extract the id of the final answer from the solution. Only return the id, never return any other text."},
+		{Role: "user", Content: fmt.Sprintf("Possible answer ids: %s\n\nSolution:\n%s", strings.Join(l.Labels(), ", "), solution)},
+	})
+	if err != nil {
+		return 0, err
+	}
+	if len(resp.Choices) == 0 {
+		return 0, ErrEmptyResponse
+	}
+
+	return extractAnswer(resp.Choices[0].Message.Content, l)
+}
@@ -0,0 +1,103 @@
+package solver
+
+import (
+	"context"
+	pl "pkg/plario"
+	"pkg/prompt"
+	"pkg/storage"
+	"strings"
+)
+
+// banked returns the verified answer of an exercise seen before, by id, by
+// content fingerprint or as a near-duplicate, with right options mapped to
+// current ids by their text. Nil when nothing matches, the answer is no
+// longer offered or there are several right options the solver can not submit.
+func (s *Solver) banked(ctx context.Context, d prompt.Data, ex *pl.Exercise) *Result {
+	if s.Bank == nil {
+		return nil
+	}
+
+	ids, err := s.Bank.CorrectAnswers(ctx, ex.ActivityID)
+	if err != nil {
+		s.logger.Warn("solver: reading bank", "message", err.Error())
+		return nil
+	}
+	if len(ids) == 1 && offered(ex, ids[0]) {
+		return &Result{AnswerID: ids[0], Banked: true, Match: MatchID, MatchedID: ex.ActivityID, MatchScore: 1, Confidence: 1}
+	}
+
+	if fp := ex.Fingerprint(); fp != "" {
+		same, err := s.Bank.FindExercises(ctx, fp)
+		if err != nil {
+			s.logger.Warn("solver: reading bank", "message", err.Error())
+			return nil
+		}
+		for _, e := range same {
+			if e.ID == ex.ActivityID {
+				continue
+			}
+			if ids, ok := MapAnswers(e, ex); ok && len(ids) == 1 {
+				return &Result{AnswerID: ids[0], Banked: true, Match: MatchFingerprint, MatchedID: e.ID, MatchScore: 1, Confidence: 1}
+			}
+		}
+	}
+
+	if s.MatchThreshold <= 0 || s.MatchThreshold > 1 {
+		return nil
+	}
+	stored, err := s.Bank.ListExercises(ctx, d.Subject.ID)
+	if err != nil {
+		s.logger.Warn("solver: reading bank", "message", err.Error())
+		return nil
+	}
+	for _, m := range NearDuplicates(ex, stored, s.MatchThreshold) {
+		if ids, ok := MapAnswers(m.Exercise, ex); ok && len(ids) == 1 {
+			return &Result{AnswerID: ids[0], Banked: true, Match: MatchSimilar, MatchedID: m.Exercise.ID, MatchScore: m.Score, Confidence: m.Score}
+		}
+	}
+	return nil
+}
+
+// Remember stores exercise with the right answers returned by the platform,
+// next time it is answered from the bank
+func (s *Solver) Remember(ctx context.Context, d prompt.Data, ex *pl.Exercise, correctIDs []int) error {
+	if s.Bank == nil {
+		return nil
+	}
+
+	if err := s.Bank.SaveExercise(ctx, BankExercise(d, ex)); err != nil {
+		return err
+	}
+	return s.Bank.SetCorrect(ctx, ex.ActivityID, correctIDs)
+}
+
+// PlarioExercise turns a bank exercise back into what the platform sent
+func PlarioExercise(e storage.Exercise) *pl.Exercise {
+	ex := &pl.Exercise{ActivityID: e.ID, Content: e.Content}
+	for _, o := range e.Options {
+		ex.PossibleAnswers = append(ex.PossibleAnswers, pl.PossibleAnswer{AnswerID: o.ID, Text: o.Text})
+	}
+	return ex
+}
+
+// BankExercise converts exercise for the bank with fingerprint and search
+// text, correctness of options is left unknown
+func BankExercise(d prompt.Data, ex *pl.Exercise) storage.Exercise {
+	e := storage.Exercise{
+		ID:           ex.ActivityID,
+		Kind:         ex.Kind(),
+		Content:      ex.Content,
+		Fingerprint:  ex.Fingerprint(),
+		QuestionText: ex.Document().Text(),
+		SubjectID:    d.Subject.ID,
+		CourseID:     d.Course.ID,
+		ModuleID:     d.Module.ID,
+	}
+	var answers []string
+	for _, a := range ex.PossibleAnswers {
+		e.Options = append(e.Options, storage.AnswerOption{ID: a.AnswerID, Text: a.Text})
+		answers = append(answers, a.Document().Text())
+	}
+	e.AnswersText = strings.Join(answers, "\n")
+	return e
+}
@@ -0,0 +1,85 @@
+package solver
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	pl "pkg/plario"
+	"pkg/storage"
+	"pkg/textsim"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// cacheKey addresses an answer by normalized question, options, models with
+// their temperature, solver settings and the rendered system prompt, so
+// changing any of them asks the model again
+func (s *Solver) cacheKey(ex *pl.Exercise, instructions string) string {
+	quiz := ex.Quiz()
+	answers := make([]string, len(quiz.Answers))
+	for i, a := range quiz.Answers {
+		answers[i] = strconv.Itoa(a.ID) + "\x1f" + textsim.Normalize(a.Option)
+	}
+	sort.Strings(answers)
+
+	var models []string
+	for _, g := range s.voters() {
+		model := string(g.Model)
+		if g.Temperature != nil {
+			model += "@" + strconv.FormatFloat(*g.Temperature, 'g', -1, 64)
+		}
+		models = append(models, model)
+	}
+
+	h := sha256.New()
+	for _, part := range append([]string{
+		strings.Join(models, ","),
+		strconv.Itoa(s.Samples),
+		strconv.FormatBool(s.Reasoning),
+		strconv.FormatBool(s.BiasCheck),
+		instructions,
+		textsim.Normalize(quiz.Question),
+	}, answers...) {
+		h.Write([]byte(part))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func (s *Solver) cached(ctx context.Context, key string) *Result {
+	if s.Bank == nil || s.CacheTTL <= 0 {
+		return nil
+	}
+
+	c, err := s.Bank.GetCachedAnswer(ctx, key)
+	if err != nil {
+		s.logger.Warn("solver: reading cache", "message", err.Error())
+		return nil
+	}
+	if c == nil {
+		return nil
+	}
+	return &Result{AnswerID: c.Answer, Content: c.Content, Confidence: c.Confidence, Cached: true}
+}
+
+// cache keeps plain model answers only, answers picked by a human, by the
+// escalation model or flagged for position bias are decided again next time
+func (s *Solver) cache(ctx context.Context, key string, r *Result) {
+	if s.Bank == nil || s.CacheTTL <= 0 || r.Human || r.Escalated || r.Flagged {
+		return
+	}
+
+	err := s.Bank.PutCachedAnswer(ctx, storage.CachedAnswer{
+		Key:        key,
+		Model:      string(s.Groq.Model),
+		Answer:     r.AnswerID,
+		Content:    r.Content,
+		Confidence: r.Confidence,
+		ExpiresAt:  time.Now().Add(s.CacheTTL),
+	})
+	if err != nil {
+		s.logger.Warn("solver: writing cache", "message", err.Error())
+	}
+}
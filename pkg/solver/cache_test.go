@@ -0,0 +1,87 @@
+package solver
+
+import (
+	pl "pkg/plario"
+	"pkg/storage"
+	"testing"
+	"time"
+)
+
+func TestCacheKeepsConfidence(t *testing.T) {
+	s := newTestSolver("a", "b", "c")
+	s.Bank = storage.NewMemory()
+	s.CacheTTL = time.Hour
+
+	// two of three models agree
+	votes := func(model string, quiz labeledQuiz) string {
+		if model == "c" {
+			return picks("5")(model, quiz)
+		}
+		return picks("4")(model, quiz)
+	}
+
+	first, err := s.Solve(t.Context(), client(votes), testData, testExercise(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := s.Solve(t.Context(), client(votes), testData, testExercise(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first.Cached || !second.Cached {
+		t.Fatalf("cached %v then %v", first.Cached, second.Cached)
+	}
+	if second.AnswerID != 251 || second.Confidence != first.Confidence || first.Confidence >= 1 {
+		t.Errorf("first %+v, cached %+v", first, second)
+	}
+}
+
+func TestCacheSkipsHumanAnswers(t *testing.T) {
+	s := newTestSolver("a", "b")
+	s.Bank = storage.NewMemory()
+	s.CacheTTL = time.Hour
+	s.MinAgreement = 0.6
+	s.Policy = PolicyAsk
+
+	asked := 0
+	s.AskHuman = func(ex *pl.Exercise, votes []Vote) (int, error) {
+		asked++
+		return 252, nil
+	}
+	disagree := func(model string, quiz labeledQuiz) string {
+		if model == "a" {
+			return picks("4")(model, quiz)
+		}
+		return picks("3")(model, quiz)
+	}
+
+	for range 2 {
+		r, err := s.Solve(t.Context(), client(disagree), testData, testExercise(1))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !r.Human || r.Cached || r.AnswerID != 252 {
+			t.Errorf("result %+v", r)
+		}
+	}
+	if asked != 2 {
+		t.Errorf("human asked %d times, want 2", asked)
+	}
+}
+
+func TestCacheKeyCoversSettings(t *testing.T) {
+	s := newTestSolver()
+	key := s.cacheKey(testExercise(1), "prompt")
+
+	s.BiasCheck = true
+	biased := s.cacheKey(testExercise(1), "prompt")
+	if biased == key {
+		t.Error("bias check does not change the key")
+	}
+
+	cold := 0.0
+	s.Groq.Temperature = &cold
+	if k := s.cacheKey(testExercise(1), "prompt"); k == biased {
+		t.Error("temperature does not change the key")
+	}
+}
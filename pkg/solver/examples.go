@@ -0,0 +1,56 @@
+package solver
+
+import (
+	"context"
+	pl "pkg/plario"
+	"pkg/prompt"
+	"pkg/storage"
+	"pkg/textsim"
+	"sort"
+)
+
+type Example struct {
+	// Exercise has a single known right option
+	Exercise storage.Exercise
+	Score    float64
+}
+
+// examples picks up to s.Examples most similar verified exercises from the
+// same module or course, same module wins on equal score
+func (s *Solver) examples(ctx context.Context, d prompt.Data, ex *pl.Exercise) ([]Example, error) {
+	if s.Bank == nil || s.Examples <= 0 {
+		return nil, nil
+	}
+
+	stored, err := s.Bank.ListExercises(ctx, d.Subject.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	query := textsim.NewVector(textsim.Tokens(ex.Document().Text()))
+
+	var examples []Example
+	for _, e := range stored {
+		if e.ID == ex.ActivityID || len(e.CorrectIDs()) != 1 || (e.ModuleID != d.Module.ID && e.CourseID != d.Course.ID) {
+			continue
+		}
+
+		score := query.Cosine(textsim.NewVector(textsim.Tokens(PlarioExercise(e).Document().Text())))
+		if score < s.Threshold {
+			continue
+		}
+		examples = append(examples, Example{Exercise: e, Score: score})
+	}
+
+	sort.SliceStable(examples, func(i, j int) bool {
+		if examples[i].Score != examples[j].Score {
+			return examples[i].Score > examples[j].Score
+		}
+		return examples[i].Exercise.ModuleID == d.Module.ID && examples[j].Exercise.ModuleID != d.Module.ID
+	})
+
+	if len(examples) > s.Examples {
+		examples = examples[:s.Examples]
+	}
+	return examples, nil
+}
@@ -0,0 +1,71 @@
+package solver
+
+import (
+	"pkg/prompt"
+	"pkg/storage"
+	"testing"
+)
+
+func TestExamples(t *testing.T) {
+	s := newTestSolver()
+	s.Bank = storage.NewMemory()
+	s.Examples = 2
+	s.Threshold = 0.1
+
+	remember := func(id int, d prompt.Data, content string, right int) {
+		ex := testExercise(id)
+		ex.Content = content
+		if err := s.Remember(t.Context(), d, ex, []int{right}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	otherCourse := testData
+	otherCourse.Course.ID, otherCourse.Module.ID = 20, 30
+	otherModule := testData
+	otherModule.Module.ID = 4
+
+	remember(2, otherModule, `<p>Сколько будет <span class="math-tex">\(2+3\)</span>?</p>`, 252)
+	remember(3, testData, `<p>Сколько будет <span class="math-tex">\(3+2\)</span>?</p>`, 252)
+	remember(4, otherCourse, `<p>Сколько будет <span class="math-tex">\(2+3\)</span>?</p>`, 252)
+	remember(5, testData, `<p>Найдите корень уравнения</p>`, 250)
+	// several right options can not be shown as an example
+	multi := testExercise(6)
+	if err := s.Remember(t.Context(), testData, multi, []int{250, 251}); err != nil {
+		t.Fatal(err)
+	}
+
+	examples, err := s.examples(t.Context(), testData, testExercise(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ids []int
+	for _, e := range examples {
+		ids = append(ids, e.Exercise.ID)
+	}
+	// same module first among equal scores, other course and unrelated text are left out
+	if len(ids) != 2 || ids[0] != 3 || ids[1] != 2 {
+		t.Errorf("examples %v", ids)
+	}
+
+	r, err := s.Solve(t.Context(), client(picks("4")), testData, testExercise(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Examples != 2 {
+		t.Errorf("%d examples sent", r.Examples)
+	}
+}
+
+func TestRememberWritesBank(t *testing.T) {
+	bank := storage.NewMemory()
+	s := newTestSolver()
+	s.Bank = bank
+
+	if err := s.Remember(t.Context(), testData, testExercise(1), []int{251}); err != nil {
+		t.Fatal(err)
+	}
+	stored, err := bank.ListExercises(t.Context(), testData.Subject.ID)
+	if err != nil || len(stored) != 1 || stored[0].Content != testExercise(1).Content {
+		t.Errorf("bank %+v %v", stored, err)
+	}
+}
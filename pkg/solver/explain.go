@@ -0,0 +1,93 @@
+package solver
+
+import (
+	"fmt"
+	"net/http"
+	"pkg/llm"
+	pl "pkg/plario"
+	"pkg/prompt"
+	"strings"
+)
+
+// Rationale asks the model for a short justification of answerID, used when
+// the answer came without reasoning
+func (s *Solver) Rationale(client *http.Client, d prompt.Data, ex *pl.Exercise, answerID int) (string, error) {
+	quiz := ex.Quiz()
+	l := relabel(quiz, identity(len(quiz.Answers)))
+	answer, ok := l.Label(answerID)
+	if !ok {
+		return "", fmt.Errorf("%w: %d", ErrAnswerNotOffered, answerID)
+	}
+
+	return s.complete(client, []llm.Message{
+		{Role: "system", Content: fmt.Sprintf("You are a tutor on %s. Answer in %s in two or three sentences, no preamble.", d.Course.Name, prompt.Language(d.Culture))},
+		{Role: "user", Content: fmt.Sprintf("%s\n\nExplain briefly why answer %s is correct.", l.String(), answer)},
+	})
+}
+
+func (s *Solver) complete(client *http.Client, messages []llm.Message) (string, error) {
+	resp, err := s.Groq.Complete(client, messages)
+	if err != nil {
+		return "", err
+	}
+	if len(resp.Choices) == 0 {
+		return "", ErrEmptyResponse
+	}
+	return strings.TrimSpace(thinkBlock.ReplaceAllString(resp.Choices[0].Message.Content, "")), nil
+}
+
+// MaxHintLevel is the last hint, it does most of the work but still leaves
+// the choice to the learner
+const MaxHintLevel = 3
+
+var hintLevels = [MaxHintLevel]string{
+	"Name the concept or rule the question is about, nothing more.",
+	"Outline the method to solve it, without calculations.",
+	"Walk through the solution up to the last step, leave the final result out.",
+}
+
+// Hint gives a hint of level 1..MaxHintLevel that never names the correct
+// option, previous are hints already shown so the next one goes further
+func (s *Solver) Hint(client *http.Client, d prompt.Data, ex *pl.Exercise, level int, previous []string) (string, error) {
+	level = min(max(level, 1), MaxHintLevel)
+	quiz := ex.Quiz()
+	l := relabel(quiz, identity(len(quiz.Answers)))
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s\n\n", l.String())
+	if len(previous) > 0 {
+		b.WriteString("Hints already given:\n")
+		for _, h := range previous {
+			fmt.Fprintf(&b, "- %s\n", h)
+		}
+		b.WriteString("\n")
+	}
+	fmt.Fprintf(&b, "Give hint %d of %d. %s", level, MaxHintLevel, hintLevels[level-1])
+
+	return s.complete(client, []llm.Message{
+		{Role: "system", Content: fmt.Sprintf("You are a tutor on %s helping a learner to solve the question on their own. Answer in %s in a few sentences, no preamble. "+
+			"Never reveal which option is correct, never name an option letter and never rule options in or out.", d.Course.Name, prompt.Language(d.Culture))},
+		{Role: "user", Content: b.String()},
+	})
+}
+
+// Explain gives a full solution after the answer is submitted, when chosen is
+// wrong it also says where the learner went astray
+func (s *Solver) Explain(client *http.Client, d prompt.Data, ex *pl.Exercise, chosen, right int) (string, error) {
+	quiz := ex.Quiz()
+	l := relabel(quiz, identity(len(quiz.Answers)))
+	rightLabel, ok := l.Label(right)
+	if !ok {
+		return "", fmt.Errorf("%w: %d", ErrAnswerNotOffered, right)
+	}
+
+	task := fmt.Sprintf("The correct answer is %s. Explain the solution step by step.", rightLabel)
+	if chosenLabel, ok := l.Label(chosen); ok && chosen != right {
+		task += fmt.Sprintf(" The learner chose %s, explain the mistake that leads to it.", chosenLabel)
+	}
+
+	return s.complete(client, []llm.Message{
+		{Role: "system", Content: fmt.Sprintf("You are a tutor on %s. Answer in %s, no preamble.", d.Course.Name, prompt.Language(d.Culture))},
+		{Role: "user", Content: fmt.Sprintf("%s\n\n%s", l.String(), task)},
+	})
+}
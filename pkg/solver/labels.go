@@ -0,0 +1,117 @@
+package solver
+
+import (
+	"encoding/json"
+	"math/rand"
+	pl "pkg/plario"
+	"strconv"
+)
+
+// raw plario answer ids like 250 and 251 are easy to mix up, the model sees
+// options relabeled A, B, C… and labels are mapped back to ids
+type labeledAnswer struct {
+	Label  string `json:"id"`
+	Option string `json:"answer"`
+}
+
+type labeledQuiz struct {
+	Question string          `json:"question"`
+	Answers  []labeledAnswer `json:"answers"`
+}
+
+type labeling struct {
+	quiz labeledQuiz
+	// ids of answers in shown order
+	ids []int
+}
+
+func label(i int) string {
+	if i < 26 {
+		return string(rune('A' + i))
+	}
+	return "A" + strconv.Itoa(i)
+}
+
+// relabel shows quiz answers in order, a permutation of answer indices
+func relabel(quiz pl.Quiz, order []int) *labeling {
+	l := &labeling{quiz: labeledQuiz{Question: quiz.Question}}
+	for i, idx := range order {
+		a := quiz.Answers[idx]
+		l.quiz.Answers = append(l.quiz.Answers, labeledAnswer{Label: label(i), Option: a.Option})
+		l.ids = append(l.ids, a.ID)
+	}
+	return l
+}
+
+func (l *labeling) String() string {
+	b, _ := json.Marshal(l.quiz)
+	return string(b)
+}
+
+func (l *labeling) Labels() []string {
+	labels := make([]string, len(l.quiz.Answers))
+	for i, a := range l.quiz.Answers {
+		labels[i] = a.Label
+	}
+	return labels
+}
+
+// Label returns label of answer id
+func (l *labeling) Label(id int) (string, bool) {
+	for i, v := range l.ids {
+		if v == id {
+			return l.quiz.Answers[i].Label, true
+		}
+	}
+	return "", false
+}
+
+// ID returns answer id of label
+func (l *labeling) ID(label string) (int, bool) {
+	for i, a := range l.quiz.Answers {
+		if a.Label == label {
+			return l.ids[i], true
+		}
+	}
+	return 0, false
+}
+
+func identity(n int) []int {
+	order := make([]int, n)
+	for i := range order {
+		order[i] = i
+	}
+	return order
+}
+
+// shuffle returns a permutation of n that differs from identity and from avoid
+func shuffle(r *rand.Rand, n int, avoid ...[]int) []int {
+	if n < 2 {
+		return identity(n)
+	}
+
+	avoid = append(avoid, identity(n))
+	for tries := 0; ; tries++ {
+		order := r.Perm(n)
+		seen := false
+		for _, a := range avoid {
+			seen = seen || equal(order, a)
+		}
+		// with two options there are only two orders
+		if !seen || tries > 16 {
+			return order
+		}
+	}
+}
+
+func equal(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
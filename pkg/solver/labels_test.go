@@ -0,0 +1,108 @@
+package solver
+
+import (
+	"errors"
+	"math/rand"
+	pl "pkg/plario"
+	"slices"
+	"testing"
+)
+
+var testQuiz = pl.Quiz{
+	Question: "2+2",
+	Answers: []pl.QuizAnswer{
+		{ID: 250, Option: "3"},
+		{ID: 251, Option: "4"},
+		{ID: 252, Option: "5"},
+	},
+}
+
+func TestRelabel(t *testing.T) {
+	l := relabel(testQuiz, []int{2, 0, 1})
+
+	if got := l.Labels(); !slices.Equal(got, []string{"A", "B", "C"}) {
+		t.Errorf("labels %v", got)
+	}
+	if got := l.String(); got != `{"question":"2+2","answers":[{"id":"A","answer":"5"},{"id":"B","answer":"3"},{"id":"C","answer":"4"}]}` {
+		t.Errorf("shown %s", got)
+	}
+	for label, id := range map[string]int{"A": 252, "B": 250, "C": 251} {
+		if got, ok := l.ID(label); !ok || got != id {
+			t.Errorf("ID(%q) = %d, %v, want %d", label, got, ok, id)
+		}
+		if got, ok := l.Label(id); !ok || got != label {
+			t.Errorf("Label(%d) = %q, %v, want %q", id, got, ok, label)
+		}
+	}
+	if _, ok := l.ID("D"); ok {
+		t.Error("ID(D) found")
+	}
+	if _, ok := l.Label(999); ok {
+		t.Error("Label(999) found")
+	}
+}
+
+func TestLabel(t *testing.T) {
+	for i, want := range map[int]string{0: "A", 25: "Z", 26: "A26", 30: "A30"} {
+		if got := label(i); got != want {
+			t.Errorf("label(%d) = %q, want %q", i, got, want)
+		}
+	}
+}
+
+func TestShuffle(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for n := 2; n <= 5; n++ {
+		for range 50 {
+			first := shuffle(r, n)
+			if equal(first, identity(n)) {
+				t.Fatalf("shuffle(%d) = identity", n)
+			}
+			sorted := slices.Sorted(slices.Values(first))
+			if !equal(sorted, identity(n)) {
+				t.Fatalf("shuffle(%d) = %v, not a permutation", n, first)
+			}
+			if n > 2 && equal(shuffle(r, n, first), first) {
+				t.Fatalf("shuffle(%d) repeated avoided %v", n, first)
+			}
+		}
+	}
+	if got := shuffle(r, 1); !equal(got, []int{0}) {
+		t.Errorf("shuffle(1) = %v", got)
+	}
+}
+
+func TestExtractAnswer(t *testing.T) {
+	l := relabel(testQuiz, []int{2, 0, 1})
+
+	tests := []struct {
+		name    string
+		content string
+		want    int
+		err     error
+	}{
+		{"label", "C", 251, nil},
+		{"lowercase", "c", 251, nil},
+		{"decorated", "**(B).**", 250, nil},
+		{"answer line", "2+2 is 4, that is C.\nANSWER: C", 251, nil},
+		{"last answer line wins", "Answer: A\nno, wait\nAnswer: B", 250, nil},
+		{"answer line in bold", "ANSWER: **A**", 252, nil},
+		{"think block", "<think>ANSWER: A</think>B", 250, nil},
+		{"offered id", "251", 251, nil},
+		{"id not offered", "999", 0, ErrAnswerNotOffered},
+		{"no label", "four", 0, ErrNoAnswer},
+		{"label not shown", "D", 0, ErrNoAnswer},
+		{"empty", "", 0, ErrNoAnswer},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := extractAnswer(tt.content, l)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("err = %v, want %v", err, tt.err)
+			}
+			if got != tt.want {
+				t.Errorf("got %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
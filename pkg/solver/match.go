@@ -0,0 +1,102 @@
+package solver
+
+import (
+	pl "pkg/plario"
+	"pkg/plario/content"
+	"pkg/storage"
+	"pkg/textsim"
+	"sort"
+)
+
+// how a banked answer was found
+const (
+	// MatchID is the same activity id
+	MatchID = "id"
+	// MatchFingerprint is the same question text under another id
+	MatchFingerprint = "fingerprint"
+	// MatchSimilar is a near-duplicate with the same options scored at least
+	// Solver.MatchThreshold
+	MatchSimilar = "similar"
+)
+
+type Match struct {
+	Exercise storage.Exercise
+	// Score is order aware similarity of question texts, 1 for identical
+	// exercises
+	Score float64
+}
+
+// NearDuplicates scores stored exercises against ex and returns those at or
+// above threshold, best first, ex itself is skipped. Options must be the same
+// texts, in any order: a near-duplicate question with other options is
+// another question.
+func NearDuplicates(ex *pl.Exercise, stored []storage.Exercise, threshold float64) []Match {
+	question := ex.Document().Text()
+	options := make([]string, 0, len(ex.PossibleAnswers))
+	for _, a := range ex.PossibleAnswers {
+		options = append(options, pl.Fingerprint(a.Text))
+	}
+
+	var matches []Match
+	for _, e := range stored {
+		if e.ID == ex.ActivityID || !sameOptions(options, e.Options) {
+			continue
+		}
+
+		score := textsim.SequenceSimilarity(question, content.Parse(e.Content).Text())
+		if score < threshold {
+			continue
+		}
+		matches = append(matches, Match{Exercise: e, Score: score})
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool { return matches[i].Score > matches[j].Score })
+	return matches
+}
+
+// sameOptions reports whether stored options have the current option
+// fingerprints, each as many times
+func sameOptions(current []string, stored []storage.AnswerOption) bool {
+	if len(current) != len(stored) {
+		return false
+	}
+	count := make(map[string]int, len(current))
+	for _, fp := range current {
+		count[fp]++
+	}
+	for _, o := range stored {
+		fp := pl.Fingerprint(o.Text)
+		if count[fp] == 0 {
+			return false
+		}
+		count[fp]--
+	}
+	return true
+}
+
+// MapAnswers finds right options of stored exercise among options of ex by
+// their text, false when one is missing or ambiguous
+func MapAnswers(stored storage.Exercise, ex *pl.Exercise) ([]int, bool) {
+	ids := make(map[string]int, len(ex.PossibleAnswers))
+	for _, a := range ex.PossibleAnswers {
+		fp := pl.Fingerprint(a.Text)
+		if _, ok := ids[fp]; ok {
+			ids[fp] = 0
+			continue
+		}
+		ids[fp] = a.AnswerID
+	}
+
+	var mapped []int
+	for _, o := range stored.Options {
+		if o.Correct == nil || !*o.Correct {
+			continue
+		}
+		id := ids[pl.Fingerprint(o.Text)]
+		if id == 0 {
+			return nil, false
+		}
+		mapped = append(mapped, id)
+	}
+	return mapped, len(mapped) > 0
+}
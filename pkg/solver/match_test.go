@@ -0,0 +1,90 @@
+package solver
+
+import (
+	pl "pkg/plario"
+	"pkg/storage"
+	"testing"
+)
+
+// bankedExercise returns exercise as stored in the bank with right answer id
+func bankedExercise(ex *pl.Exercise, right int) storage.Exercise {
+	e := BankExercise(testData, ex)
+	for i := range e.Options {
+		correct := e.Options[i].ID == right
+		e.Options[i].Correct = &correct
+	}
+	return e
+}
+
+func exerciseWith(id int, question string, options ...string) *pl.Exercise {
+	ex := &pl.Exercise{ActivityID: id, Content: question}
+	for i, o := range options {
+		ex.PossibleAnswers = append(ex.PossibleAnswers, pl.PossibleAnswer{AnswerID: id*10 + i, Text: o})
+	}
+	return ex
+}
+
+const longQuestion = `<p>Турист прошел в первый день 12 км, во второй день на 3 км больше, а в третий день столько же, сколько в первые два дня вместе. Сколько километров прошел турист за три дня? Запишите ответ числом: <span class="math-tex">\(a-b\)</span></p>`
+
+func TestNearDuplicates(t *testing.T) {
+	stored := exerciseWith(1, longQuestion, "<p>54</p>", "<p>45</p>", "<p>27</p>")
+
+	tests := []struct {
+		name string
+		ex   *pl.Exercise
+		want bool
+	}{
+		{"options in another order", exerciseWith(2, longQuestion, "<p>27</p>", "<p>54</p>", "<p>45</p>"), true},
+		{"one more word", exerciseWith(2, longQuestion[:len(longQuestion)-4]+" ниже</p>", "<p>54</p>", "<p>45</p>", "<p>27</p>"), true},
+		{"swapped operands", exerciseWith(2, `<p>Турист прошел в первый день 12 км, во второй день на 3 км больше, а в третий день столько же, сколько в первые два дня вместе. Сколько километров прошел турист за три дня? Запишите ответ числом: <span class="math-tex">\(b-a\)</span></p>`,
+			"<p>54</p>", "<p>45</p>", "<p>27</p>"), false},
+		{"other sign", exerciseWith(2, `<p>Турист прошел в первый день 12 км, во второй день на 3 км больше, а в третий день столько же, сколько в первые два дня вместе. Сколько километров прошел турист за три дня? Запишите ответ числом: <span class="math-tex">\(a+b\)</span></p>`,
+			"<p>54</p>", "<p>45</p>", "<p>27</p>"), false},
+		{"other option text", exerciseWith(2, longQuestion, "<p>54</p>", "<p>45</p>", "<p>-27</p>"), false},
+		{"extra option", exerciseWith(2, longQuestion, "<p>54</p>", "<p>45</p>", "<p>27</p>", "<p>36</p>"), false},
+		{"missing option", exerciseWith(2, longQuestion, "<p>54</p>", "<p>45</p>"), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			matches := NearDuplicates(tt.ex, []storage.Exercise{bankedExercise(stored, 10)}, 0.95)
+			if got := len(matches) == 1; got != tt.want {
+				t.Errorf("matched %v, want %v (%+v)", got, tt.want, matches)
+			}
+		})
+	}
+}
+
+func TestBankedNearDuplicate(t *testing.T) {
+	stored := exerciseWith(1, longQuestion, "<p>54</p>", "<p>45</p>", "<p>27</p>")
+	similar := exerciseWith(2, longQuestion[:len(longQuestion)-4]+" ниже</p>", "<p>27</p>", "<p>54</p>", "<p>45</p>")
+
+	s := newTestSolver()
+	s.Bank = storage.NewMemory()
+	if err := s.Bank.SaveExercise(t.Context(), bankedExercise(stored, 10)); err != nil {
+		t.Fatal(err)
+	}
+
+	if r := s.banked(t.Context(), testData, similar); r != nil {
+		t.Errorf("near-duplicate answered by default: %+v", r)
+	}
+
+	s.MatchThreshold = 0.95
+	r := s.banked(t.Context(), testData, similar)
+	if r == nil || r.Match != MatchSimilar || r.MatchedID != 1 || r.AnswerID != 21 {
+		t.Errorf("result %+v", r)
+	}
+}
+
+func TestMapAnswers(t *testing.T) {
+	stored := bankedExercise(exerciseWith(1, longQuestion, "<p>54</p>", "<p>45</p>", "<p>27</p>"), 10)
+
+	if ids, ok := MapAnswers(stored, exerciseWith(2, longQuestion, "<p>45</p>", "<p>27</p>", "<p>54</p>")); !ok || len(ids) != 1 || ids[0] != 22 {
+		t.Errorf("mapped %v %v, want [22]", ids, ok)
+	}
+	if _, ok := MapAnswers(stored, exerciseWith(2, longQuestion, "<p>45</p>", "<p>27</p>", "<p>-54</p>")); ok {
+		t.Error("mapped to another sign")
+	}
+	if _, ok := MapAnswers(stored, exerciseWith(2, longQuestion, "<p>54</p>", "<p>54</p>")); ok {
+		t.Error("mapped ambiguous option")
+	}
+}
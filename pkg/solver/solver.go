@@ -0,0 +1,318 @@
+package solver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/rand"
+	"net/http"
+	"pkg/llm"
+	pl "pkg/plario"
+	"pkg/prompt"
+	"pkg/retrieval"
+	"pkg/storage"
+	"slices"
+	"strings"
+	"time"
+)
+
+var (
+	ErrEmptyResponse = errors.New("llm returned no choices")
+	ErrNoAnswer      = errors.New("no answer id in llm response")
+	// ErrAnswerNotOffered is returned when the model names an answer id
+	// which is not among exercise options
+	ErrAnswerNotOffered = errors.New("answer is not one of offered options")
+	// ErrPositionBias is returned when the answer depends on options order
+	// and Policy could not settle it
+	ErrPositionBias = errors.New("answer changes with options order")
+	// ErrLowAgreement is returned when samples disagree and Policy could
+	// not settle it
+	ErrLowAgreement = errors.New("samples do not agree on answer")
+)
+
+// Solver turns an exercise into an answer id: it renders the system prompt,
+// attaches similar verified questions from the bank as few-shot examples and
+// asks the model
+type Solver struct {
+	Groq    *llm.Groq
+	Prompts *prompt.Resolver
+
+	// Bank is optional store of verified exercises consulted before the model
+	// and drawn on for examples, answers cache and reasonings are kept there
+	// too, without it none of these are used
+	Bank storage.Store
+	// Examples is maximum number of few-shot examples per question
+	Examples int
+	// Threshold is minimum similarity for a bank question to become an example
+	Threshold float64
+	// MatchThreshold is minimum score of a near-duplicate bank exercise to
+	// answer from it, 0 (the default) disables near-duplicate matching
+	MatchThreshold float64
+
+	// Theory is optional index of module theory lessons
+	Theory *retrieval.Index
+	// TheoryChunks is maximum number of theory chunks per question
+	TheoryChunks int
+
+	// Reasoning lets the model think before answering, the answer is then
+	// extracted from its output and the reasoning is stored in Bank
+	Reasoning bool
+
+	// CacheTTL keeps answers in Bank for repeated questions, 0 disables cache
+	CacheTTL time.Duration
+
+	// BiasCheck asks again with shuffled options and flags disagreements
+	BiasCheck bool
+
+	// Samples is number of answers drawn from every voter, more than one
+	// turns on majority voting
+	Samples int
+	// Ensemble replaces Groq as voters when set, each model gives Samples answers
+	Ensemble []*llm.Groq
+	// MinAgreement is minimum share of samples that must agree on the answer
+	MinAgreement float64
+	// Policy settles ties and low agreement: PolicyEscalate, PolicySkip or PolicyAsk
+	Policy string
+	// Escalate is a stronger model asked by PolicyEscalate
+	Escalate *llm.Groq
+	// AskHuman picks the answer for PolicyAsk
+	AskHuman func(ex *pl.Exercise, votes []Vote) (int, error)
+
+	rand   *rand.Rand
+	logger *slog.Logger
+}
+
+func New(groq *llm.Groq, prompts *prompt.Resolver, logger *slog.Logger) *Solver {
+	return &Solver{
+		Groq:      groq,
+		Prompts:   prompts,
+		Examples:  3,
+		Threshold: 0.3,
+
+		TheoryChunks: 3,
+
+		Samples: 1,
+		Policy:  PolicySkip,
+
+		rand:   rand.New(rand.NewSource(time.Now().UnixNano())),
+		logger: logger,
+	}
+}
+
+type Result struct {
+	AnswerID int
+	// Content is raw model output
+	Content string
+	// Examples is number of few-shot examples sent along
+	Examples int
+	// Theory is number of theory chunks sent along
+	Theory int
+	// Reasoning is model thoughts when Solver.Reasoning is on
+	Reasoning string
+	// Cached is set when answer came from cache without asking the model
+	Cached bool
+	// Banked is set when answer is the verified one from the question bank
+	Banked bool
+	// Match tells how the banked exercise was found, MatchedID is its id and
+	// MatchScore its similarity
+	Match      string
+	MatchedID  int
+	MatchScore float64
+
+	// BiasAnswers are answers given for each option order when Solver.BiasCheck is on
+	BiasAnswers []int
+	// Flagged is set when answers differ between option orders, the answer
+	// is then the one Policy settled on
+	Flagged bool
+
+	// Votes are answers of all samples, most common first, empty for a single sample
+	Votes []Vote
+	// Confidence is share of samples agreeing with the winner
+	Confidence float64
+	// Escalated is set when the answer came from Solver.Escalate
+	Escalated bool
+	// Human is set when the answer was picked by Solver.AskHuman
+	Human bool
+}
+
+// sample is a single model answer for one order of options
+type sample struct {
+	model     llm.Model
+	answer    int
+	content   string
+	reasoning string
+	order     []int
+}
+
+func (s *Solver) Solve(ctx context.Context, client *http.Client, d prompt.Data, ex *pl.Exercise) (*Result, error) {
+	d.Kind = ex.Kind()
+	d.Reasoning = s.Reasoning
+	instructions, err := s.Prompts.Render(d)
+	if err != nil {
+		return nil, err
+	}
+
+	if r := s.banked(ctx, d, ex); r != nil {
+		return r, nil
+	}
+
+	key := s.cacheKey(ex, instructions)
+	if r := s.cached(ctx, key); r != nil {
+		return r, nil
+	}
+
+	examples, err := s.examples(ctx, d, ex)
+	if err != nil {
+		s.logger.Warn("solver.examples", "message", err.Error())
+	}
+	theory := s.theory(ctx, client, d, ex)
+
+	prefix, used := s.prefix(instructions, examples, theory)
+	quiz := ex.Quiz()
+
+	result := &Result{Examples: used, Theory: len(theory), Confidence: 1}
+	first, err := s.vote(client, ex, prefix, quiz, result)
+	if err != nil {
+		return nil, err
+	}
+	if s.BiasCheck && len(quiz.Answers) > 1 && !result.Human {
+		if first, err = s.checkBias(client, ex, prefix, quiz, first, result); err != nil {
+			return nil, err
+		}
+	}
+	result.AnswerID = first.answer
+	result.Content = first.content
+	result.Reasoning = first.reasoning
+
+	if s.Bank != nil && result.Reasoning != "" {
+		err := s.Bank.CreateReasoning(ctx, storage.Reasoning{
+			QuestionID: ex.ActivityID,
+			Model:      string(first.model),
+			Reasoning:  result.Reasoning,
+			Answer:     result.AnswerID,
+			CourseID:   d.Course.ID,
+			ModuleID:   d.Module.ID,
+		})
+		if err != nil {
+			s.logger.Warn("solver: storing reasoning", "message", err.Error())
+		}
+	}
+
+	s.cache(ctx, key, result)
+	return result, nil
+}
+
+// prefix builds messages preceding the question: system prompt, theory and
+// few-shot examples relabeled the same way as the question. Examples whose
+// right answer is not among their options are dropped, the number of used
+// ones is returned.
+func (s *Solver) prefix(instructions string, examples []Example, theory []retrieval.Hit) ([]llm.Message, int) {
+	messages := []llm.Message{{Role: "system", Content: instructions}}
+	if len(theory) > 0 {
+		messages = append(messages, llm.Message{Role: "system", Content: theoryMessage(theory)})
+	}
+
+	used := 0
+	for _, e := range examples {
+		quiz := PlarioExercise(e.Exercise).Quiz()
+		l := relabel(quiz, identity(len(quiz.Answers)))
+		right, ok := l.Label(e.Exercise.CorrectIDs()[0])
+		if !ok {
+			continue
+		}
+
+		answer := right
+		if s.Reasoning {
+			answer = "ANSWER: " + right
+		}
+		messages = append(messages,
+			llm.Message{Role: "user", Content: l.String()},
+			llm.Message{Role: "assistant", Content: answer},
+		)
+		used++
+	}
+	return messages, used
+}
+
+// ask shows quiz options in order and maps the answer back to an answer id
+func (s *Solver) ask(client *http.Client, g *llm.Groq, prefix []llm.Message, quiz pl.Quiz, order []int) (*sample, error) {
+	l := relabel(quiz, order)
+	messages := append(slices.Clone(prefix), llm.Message{Role: "user", Content: l.String()})
+
+	resp, err := g.Complete(client, messages)
+	if err != nil {
+		return nil, err
+	}
+	if len(resp.Choices) == 0 {
+		return nil, ErrEmptyResponse
+	}
+
+	msg := resp.Choices[0].Message
+	smp := &sample{model: g.Model, content: strings.TrimSpace(msg.Content), order: order}
+	if s.Reasoning {
+		smp.reasoning = strings.TrimSpace(msg.Reasoning)
+		if smp.reasoning == "" {
+			smp.reasoning = smp.content
+		}
+	}
+
+	smp.answer, err = extractAnswer(smp.content, l)
+	if errors.Is(err, ErrNoAnswer) && s.Reasoning {
+		smp.answer, err = s.extract(client, g, smp.content, l)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return smp, nil
+}
+
+// checkBias asks again with shuffled options. When the answer moves with the
+// order the result is flagged and Policy settles it as low agreement is
+// settled, PolicySkip returns ErrPositionBias.
+func (s *Solver) checkBias(client *http.Client, ex *pl.Exercise, prefix []llm.Message, quiz pl.Quiz, first *sample, result *Result) (*sample, error) {
+	result.BiasAnswers = []int{first.answer}
+
+	second, err := s.ask(client, s.voter(first.model), prefix, quiz, shuffle(s.rand, len(quiz.Answers)))
+	if err != nil {
+		return nil, err
+	}
+	result.BiasAnswers = append(result.BiasAnswers, second.answer)
+	if second.answer == first.answer {
+		return first, nil
+	}
+
+	result.Flagged = true
+	if len(result.Votes) == 0 {
+		result.Votes = tally([]*sample{first, second})
+	}
+	return s.resolve(client, ex, prefix, quiz, result, fmt.Errorf("%w: answers %v", ErrPositionBias, result.BiasAnswers))
+}
+
+func (s *Solver) theory(ctx context.Context, client *http.Client, d prompt.Data, ex *pl.Exercise) []retrieval.Hit {
+	if s.Theory == nil || s.TheoryChunks <= 0 {
+		return nil
+	}
+
+	quiz := ex.Quiz()
+	query := quiz.Question
+	for _, a := range quiz.Answers {
+		query += " " + a.Option
+	}
+
+	hits, err := s.Theory.Search(ctx, client, d.Module.ID, query, s.TheoryChunks)
+	if err != nil {
+		s.logger.Warn("solver.theory", "message", err.Error())
+	}
+	return hits
+}
+
+func theoryMessage(hits []retrieval.Hit) string {
+	var b strings.Builder
+	b.WriteString("Theory from this module that may help to answer:")
+	for _, h := range hits {
+		b.WriteString("\n---\n")
+		b.WriteString(h.Chunk.Content)
+	}
+	return b.String()
+}
@@ -0,0 +1,189 @@
+package solver
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"pkg/llm"
+	pl "pkg/plario"
+	"pkg/prompt"
+	"slices"
+	"testing"
+)
+
+// fakeLLM answers chat completions in place of groq, it gets the model name
+// and the quiz shown to it
+type fakeLLM func(model string, quiz labeledQuiz) string
+
+func (f fakeLLM) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body llm.GroqRequest
+	if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+		return nil, err
+	}
+	var quiz labeledQuiz
+	json.Unmarshal([]byte(body.Messages[len(body.Messages)-1].Content), &quiz)
+
+	b, _ := json.Marshal(llm.GroqResponse{Choices: []llm.Choice{
+		{Message: llm.Message{Role: "assistant", Content: f(body.Model, quiz)}},
+	}})
+	return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(bytes.NewReader(b)), Header: make(http.Header)}, nil
+}
+
+// picks answers the label of the option with text, whatever order it is shown in
+func picks(text string) fakeLLM {
+	return func(_ string, quiz labeledQuiz) string {
+		for _, a := range quiz.Answers {
+			if a.Option == text {
+				return a.Label
+			}
+		}
+		return "no idea"
+	}
+}
+
+// picksFirst answers the label shown first, a model with position bias
+func picksFirst(string, labeledQuiz) string {
+	return "A"
+}
+
+var discard = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+func newTestSolver(models ...llm.Model) *Solver {
+	if len(models) == 0 {
+		models = []llm.Model{"test-model"}
+	}
+	s := New(llm.NewGroq("", models[0], "", discard), prompt.NewResolver(""), discard)
+	if len(models) > 1 {
+		for _, m := range models {
+			s.Ensemble = append(s.Ensemble, llm.NewGroq("", m, "", discard))
+		}
+	}
+	return s
+}
+
+func client(f fakeLLM) *http.Client {
+	return &http.Client{Transport: f}
+}
+
+// exercise with options 250 "3", 251 "4" and 252 "5"
+func testExercise(id int) *pl.Exercise {
+	return &pl.Exercise{
+		ActivityID: id,
+		Content:    `<p>Сколько будет <span class="math-tex">\(2+2\)</span>?</p>`,
+		PossibleAnswers: []pl.PossibleAnswer{
+			{AnswerID: 250, Text: "<p>3</p>"},
+			{AnswerID: 251, Text: "<p>4</p>"},
+			{AnswerID: 252, Text: "<p>5</p>"},
+		},
+	}
+}
+
+var testData = prompt.Data{
+	Subject: prompt.Named{ID: 1, Name: "Математика"},
+	Course:  prompt.Named{ID: 2, Name: "Алгебра"},
+	Module:  prompt.Named{ID: 3, Name: "Сложение"},
+	Culture: "ru",
+}
+
+func TestSolve(t *testing.T) {
+	s := newTestSolver()
+	r, err := s.Solve(t.Context(), client(picks("4")), testData, testExercise(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.AnswerID != 251 || r.Confidence != 1 || r.Cached || r.Banked {
+		t.Errorf("result %+v", r)
+	}
+}
+
+func TestSolveRejectsUnknownAnswer(t *testing.T) {
+	s := newTestSolver()
+	_, err := s.Solve(t.Context(), client(func(string, labeledQuiz) string { return "999" }), testData, testExercise(1))
+	if !errors.Is(err, ErrAnswerNotOffered) {
+		t.Errorf("err = %v, want %v", err, ErrAnswerNotOffered)
+	}
+}
+
+// inTurn answers the option with the i-th text on the i-th call, the last
+// one after that, a model whose answer moves with the options order
+func inTurn(texts ...string) fakeLLM {
+	calls := 0
+	return func(model string, quiz labeledQuiz) string {
+		text := texts[min(calls, len(texts)-1)]
+		calls++
+		return picks(text)(model, quiz)
+	}
+}
+
+func TestBiasCheck(t *testing.T) {
+	s := newTestSolver()
+	s.BiasCheck = true
+
+	r, err := s.Solve(t.Context(), client(picks("4")), testData, testExercise(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.AnswerID != 251 || r.Flagged || !slices.Equal(r.BiasAnswers, []int{251, 251}) {
+		t.Errorf("result %+v", r)
+	}
+}
+
+func TestBiasCheckSkip(t *testing.T) {
+	s := newTestSolver()
+	s.BiasCheck = true
+
+	_, err := s.Solve(t.Context(), client(inTurn("4", "3", "4")), testData, testExercise(1))
+	if !errors.Is(err, ErrPositionBias) {
+		t.Errorf("err = %v, want %v", err, ErrPositionBias)
+	}
+}
+
+func TestBiasCheckAsk(t *testing.T) {
+	s := newTestSolver()
+	s.BiasCheck = true
+	s.Policy = PolicyAsk
+
+	var shown []Vote
+	s.AskHuman = func(ex *pl.Exercise, votes []Vote) (int, error) {
+		shown = votes
+		return 252, nil
+	}
+
+	// a majority of two orders must not be submitted on its own
+	r, err := s.Solve(t.Context(), client(inTurn("4", "3", "3")), testData, testExercise(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.AnswerID != 252 || !r.Human || !r.Flagged {
+		t.Errorf("result %+v", r)
+	}
+	if want := []Vote{{251, 1}, {250, 1}}; !slices.Equal(shown, want) {
+		t.Errorf("human was shown %v, want %v", shown, want)
+	}
+}
+
+func TestBiasCheckEscalate(t *testing.T) {
+	s := newTestSolver()
+	s.BiasCheck = true
+	s.Policy = PolicyEscalate
+	s.Escalate = llm.NewGroq("", "strong-model", "", discard)
+
+	weak := inTurn("4", "3")
+	models := func(model string, quiz labeledQuiz) string {
+		if model == "strong-model" {
+			return picks("5")(model, quiz)
+		}
+		return weak(model, quiz)
+	}
+
+	r, err := s.Solve(t.Context(), client(models), testData, testExercise(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.AnswerID != 252 || !r.Escalated || !r.Flagged || !slices.Equal(r.BiasAnswers, []int{251, 250}) {
+		t.Errorf("result %+v", r)
+	}
+}
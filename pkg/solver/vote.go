@@ -0,0 +1,155 @@
+package solver
+
+import (
+	"fmt"
+	"net/http"
+	"pkg/llm"
+	pl "pkg/plario"
+	"sort"
+)
+
+// what to do when samples do not agree enough
+const (
+	// PolicyEscalate asks Solver.Escalate model once and takes its answer
+	PolicyEscalate = "escalate"
+	// PolicySkip returns ErrLowAgreement or ErrPositionBias, nothing is submitted
+	PolicySkip = "skip"
+	// PolicyAsk lets Solver.AskHuman pick the answer
+	PolicyAsk = "ask"
+)
+
+func ValidPolicy(p string) bool {
+	switch p {
+	case PolicyEscalate, PolicySkip, PolicyAsk:
+		return true
+	}
+	return false
+}
+
+// Vote is one answer and how many samples gave it
+type Vote struct {
+	AnswerID int
+	Count    int
+}
+
+// voters returns models asked for every sample round, the ensemble or the
+// main model
+func (s *Solver) voters() []*llm.Groq {
+	if len(s.Ensemble) > 0 {
+		return s.Ensemble
+	}
+	return []*llm.Groq{s.Groq}
+}
+
+// vote draws Samples answers from every voter and picks the most common one.
+// Ties and agreement below MinAgreement are resolved by Policy.
+func (s *Solver) vote(client *http.Client, ex *pl.Exercise, prefix []llm.Message, quiz pl.Quiz, result *Result) (*sample, error) {
+	order := identity(len(quiz.Answers))
+	voters := s.voters()
+	rounds := max(s.Samples, 1)
+
+	if len(voters)*rounds == 1 {
+		return s.ask(client, voters[0], prefix, quiz, order)
+	}
+
+	var samples []*sample
+	var lastErr error
+	for range rounds {
+		for _, g := range voters {
+			smp, err := s.ask(client, g, prefix, quiz, order)
+			if err != nil {
+				s.logger.Warn("solver: sample", "model", g.Model, "message", err.Error())
+				lastErr = err
+				continue
+			}
+			samples = append(samples, smp)
+		}
+	}
+	if len(samples) == 0 {
+		return nil, lastErr
+	}
+
+	votes := tally(samples)
+	result.Votes = votes
+	result.Confidence = float64(votes[0].Count) / float64(len(samples))
+
+	tie := len(votes) > 1 && votes[0].Count == votes[1].Count
+	if !tie && result.Confidence >= s.MinAgreement {
+		for _, smp := range samples {
+			if smp.answer == votes[0].AnswerID {
+				return smp, nil
+			}
+		}
+	}
+
+	return s.resolve(client, ex, prefix, quiz, result, fmt.Errorf("%w: %.2f agreement, votes %v", ErrLowAgreement, result.Confidence, result.Votes))
+}
+
+// voter finds the model that gave an answer, bias check asks the same one
+func (s *Solver) voter(model llm.Model) *llm.Groq {
+	for _, g := range s.voters() {
+		if g.Model == model {
+			return g
+		}
+	}
+	if s.Escalate != nil && s.Escalate.Model == model {
+		return s.Escalate
+	}
+	return s.Groq
+}
+
+func tally(samples []*sample) []Vote {
+	counts := make(map[int]int)
+	var order []int
+	for _, smp := range samples {
+		if counts[smp.answer] == 0 {
+			order = append(order, smp.answer)
+		}
+		counts[smp.answer]++
+	}
+
+	votes := make([]Vote, len(order))
+	for i, id := range order {
+		votes[i] = Vote{AnswerID: id, Count: counts[id]}
+	}
+	// first seen wins among equal counts, it keeps ties visible to resolve
+	sort.SliceStable(votes, func(i, j int) bool { return votes[i].Count > votes[j].Count })
+	return votes
+}
+
+// resolve settles an unreliable answer by Policy, unreliable is returned when
+// the policy can not
+func (s *Solver) resolve(client *http.Client, ex *pl.Exercise, prefix []llm.Message, quiz pl.Quiz, result *Result, unreliable error) (*sample, error) {
+	switch s.Policy {
+	case PolicyEscalate:
+		if s.Escalate == nil {
+			break
+		}
+		result.Escalated = true
+		return s.ask(client, s.Escalate, prefix, quiz, identity(len(quiz.Answers)))
+	case PolicyAsk:
+		if s.AskHuman == nil {
+			break
+		}
+		answer, err := s.AskHuman(ex, result.Votes)
+		if err != nil {
+			return nil, err
+		}
+		if !offered(ex, answer) {
+			return nil, fmt.Errorf("%w: %d", ErrAnswerNotOffered, answer)
+		}
+		result.Human = true
+		return &sample{answer: answer}, nil
+	}
+
+	return nil, unreliable
+}
+
+func offered(ex *pl.Exercise, id int) bool {
+	for _, a := range ex.PossibleAnswers {
+		if a.AnswerID == id {
+			return true
+		}
+	}
+	return false
+}
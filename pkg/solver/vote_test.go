@@ -0,0 +1,130 @@
+package solver
+
+import (
+	"errors"
+	"pkg/llm"
+	pl "pkg/plario"
+	"slices"
+	"testing"
+)
+
+func TestTally(t *testing.T) {
+	samples := func(answers ...int) []*sample {
+		var s []*sample
+		for _, a := range answers {
+			s = append(s, &sample{answer: a})
+		}
+		return s
+	}
+
+	tests := []struct {
+		name    string
+		answers []int
+		want    []Vote
+	}{
+		{"single", []int{251}, []Vote{{251, 1}}},
+		{"majority first", []int{250, 251, 251}, []Vote{{251, 2}, {250, 1}}},
+		{"tie keeps first seen", []int{252, 250}, []Vote{{252, 1}, {250, 1}}},
+		{"all agree", []int{251, 251, 251}, []Vote{{251, 3}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tally(samples(tt.answers...)); !slices.Equal(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// byModel answers with fake of the model asked
+func byModel(fakes map[string]fakeLLM) fakeLLM {
+	return func(model string, quiz labeledQuiz) string {
+		return fakes[model](model, quiz)
+	}
+}
+
+func TestVote(t *testing.T) {
+	// a and b agree, c does not, strong is the escalation model
+	twoOfThree := byModel(map[string]fakeLLM{"a": picks("4"), "b": picks("4"), "c": picks("5"), "strong": picks("3")})
+	// a and b disagree
+	tie := byModel(map[string]fakeLLM{"a": picks("4"), "b": picks("5"), "strong": picks("3")})
+
+	tests := []struct {
+		name         string
+		models       []llm.Model
+		minAgreement float64
+		policy       string
+		human        int
+		llm          fakeLLM
+		want         int
+		confidence   float64
+		escalated    bool
+		asked        bool
+		err          error
+	}{
+		{name: "majority", models: []llm.Model{"a", "b", "c"}, minAgreement: 0.5, policy: PolicySkip,
+			llm: twoOfThree, want: 251, confidence: 2.0 / 3},
+		{name: "low agreement skip", models: []llm.Model{"a", "b", "c"}, minAgreement: 0.9, policy: PolicySkip,
+			llm: twoOfThree, err: ErrLowAgreement},
+		{name: "tie skip", models: []llm.Model{"a", "b"}, minAgreement: 0.5, policy: PolicySkip,
+			llm: tie, err: ErrLowAgreement},
+		{name: "tie escalate", models: []llm.Model{"a", "b"}, minAgreement: 0.5, policy: PolicyEscalate,
+			llm: tie, want: 250, confidence: 0.5, escalated: true},
+		{name: "low agreement ask", models: []llm.Model{"a", "b", "c"}, minAgreement: 0.9, policy: PolicyAsk, human: 252,
+			llm: twoOfThree, want: 252, confidence: 2.0 / 3, asked: true},
+		{name: "human picks unknown id", models: []llm.Model{"a", "b"}, minAgreement: 0.5, policy: PolicyAsk, human: 999,
+			llm: tie, asked: true, err: ErrAnswerNotOffered},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestSolver(tt.models...)
+			s.MinAgreement = tt.minAgreement
+			s.Policy = tt.policy
+			s.Escalate = llm.NewGroq("", "strong", "", discard)
+			asked := false
+			s.AskHuman = func(ex *pl.Exercise, votes []Vote) (int, error) {
+				asked = true
+				return tt.human, nil
+			}
+
+			r, err := s.Solve(t.Context(), client(tt.llm), testData, testExercise(1))
+			if asked != tt.asked {
+				t.Errorf("human asked %v, want %v", asked, tt.asked)
+			}
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("err = %v, want %v", err, tt.err)
+			}
+			if err != nil {
+				return
+			}
+			if r.AnswerID != tt.want || r.Confidence != tt.confidence || r.Escalated != tt.escalated || r.Human != tt.asked {
+				t.Errorf("result %+v", r)
+			}
+		})
+	}
+}
+
+func TestVoteSamples(t *testing.T) {
+	s := newTestSolver()
+	s.Samples = 4
+	s.MinAgreement = 0.7
+
+	r, err := s.Solve(t.Context(), client(inTurn("4", "4", "4", "3")), testData, testExercise(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.AnswerID != 251 || r.Confidence != 0.75 || !slices.Equal(r.Votes, []Vote{{251, 3}, {250, 1}}) {
+		t.Errorf("result %+v", r)
+	}
+}
+
+func TestValidPolicy(t *testing.T) {
+	for _, p := range []string{PolicyEscalate, PolicySkip, PolicyAsk} {
+		if !ValidPolicy(p) {
+			t.Errorf("%q is not valid", p)
+		}
+	}
+	if ValidPolicy("retry") {
+		t.Error(`"retry" is valid`)
+	}
+}
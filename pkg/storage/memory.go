@@ -0,0 +1,465 @@
+package storage
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Memory is an in-process Store, it is lost on exit
+type Memory struct {
+	mu sync.Mutex
+
+	subjects map[int]CatalogEntry
+	courses  map[int]CatalogEntry
+	modules  map[int]CatalogEntry
+
+	exercises map[int]Exercise
+	attempts  []Attempt
+	runs      []Run
+	mastery   []MasterySample
+
+	reasonings []Reasoning
+	cache      map[string]CachedAnswer
+	decisions  []Decision
+	mistakes   []Mistake
+	theory     []TheoryChunk
+}
+
+var _ Store = (*Memory)(nil)
+
+func NewMemory() *Memory {
+	return &Memory{
+		subjects:  make(map[int]CatalogEntry),
+		courses:   make(map[int]CatalogEntry),
+		modules:   make(map[int]CatalogEntry),
+		exercises: make(map[int]Exercise),
+		cache:     make(map[string]CachedAnswer),
+	}
+}
+
+func (m *Memory) SyncCatalog(ctx context.Context, c Catalog) (SyncResult, error) {
+	if err := ctx.Err(); err != nil {
+		return SyncResult{}, err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var res SyncResult
+	tables := []struct {
+		stored  map[int]CatalogEntry
+		entries []CatalogEntry
+	}{
+		{m.subjects, c.Subjects},
+		{m.courses, c.Courses},
+		{m.modules, c.Modules},
+	}
+	for _, t := range tables {
+		for _, e := range t.entries {
+			old, ok := t.stored[e.ID]
+			switch {
+			case !ok:
+				res.Added++
+			case old.Name != e.Name && e.Name != "":
+				res.Renamed++
+			default:
+				res.Unchanged++
+			}
+			if e.Name == "" {
+				e.Name = old.Name
+			}
+			t.stored[e.ID] = e
+		}
+	}
+	return res, nil
+}
+
+func (m *Memory) SaveExercise(ctx context.Context, e Exercise) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now().UTC()
+	e.CreatedAt, e.UpdatedAt = now, now
+	old, ok := m.exercises[e.ID]
+	if ok {
+		e.CreatedAt = old.CreatedAt
+	}
+
+	// options are upserted as the database does, ones missing from e stay
+	// after the saved ones
+	options := make([]AnswerOption, 0, len(e.Options))
+	saved := make(map[int]bool, len(e.Options))
+	for _, o := range e.Options {
+		if o.Correct == nil && ok {
+			for _, prev := range old.Options {
+				if prev.ID == o.ID {
+					o.Correct = prev.Correct
+				}
+			}
+		}
+		saved[o.ID] = true
+		options = append(options, copyOption(o))
+	}
+	for _, prev := range old.Options {
+		if !saved[prev.ID] {
+			options = append(options, copyOption(prev))
+		}
+	}
+	e.Options = options
+
+	m.exercises[e.ID] = e
+	return nil
+}
+
+func (m *Memory) SetCorrect(ctx context.Context, exerciseID int, correctIDs []int) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	e, ok := m.exercises[exerciseID]
+	if !ok {
+		return nil
+	}
+	for i := range e.Options {
+		correct := false
+		for _, id := range correctIDs {
+			if e.Options[i].ID == id {
+				correct = true
+			}
+		}
+		e.Options[i].Correct = &correct
+	}
+	return nil
+}
+
+func (m *Memory) GetExercise(ctx context.Context, id int) (*Exercise, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	e, ok := m.exercises[id]
+	if !ok {
+		return nil, nil
+	}
+	e = copyExercise(e)
+	return &e, nil
+}
+
+func (m *Memory) FindExercises(ctx context.Context, fingerprint string) ([]Exercise, error) {
+	found, err := m.filter(ctx, func(e Exercise) bool { return e.Fingerprint == fingerprint })
+	sort.SliceStable(found, func(i, j int) bool { return found[i].UpdatedAt.After(found[j].UpdatedAt) })
+	return found, err
+}
+
+func (m *Memory) ListExercises(ctx context.Context, subjectID int) ([]Exercise, error) {
+	found, err := m.filter(ctx, func(e Exercise) bool {
+		return (subjectID == 0 || e.SubjectID == subjectID) && len(e.CorrectIDs()) > 0
+	})
+	sort.Slice(found, func(i, j int) bool { return found[i].ID < found[j].ID })
+	return found, err
+}
+
+func (m *Memory) AllExercises(ctx context.Context) ([]Exercise, error) {
+	found, err := m.filter(ctx, func(Exercise) bool { return true })
+	sort.Slice(found, func(i, j int) bool { return found[i].ID < found[j].ID })
+	return found, err
+}
+
+func (m *Memory) filter(ctx context.Context, keep func(Exercise) bool) ([]Exercise, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var found []Exercise
+	for _, e := range m.exercises {
+		if keep(e) {
+			found = append(found, copyExercise(e))
+		}
+	}
+	return found, nil
+}
+
+func (m *Memory) CorrectAnswers(ctx context.Context, exerciseID int) ([]int, error) {
+	e, err := m.GetExercise(ctx, exerciseID)
+	if err != nil || e == nil {
+		return nil, err
+	}
+	return e.CorrectIDs(), nil
+}
+
+func (m *Memory) CreateAttempt(ctx context.Context, a Attempt) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	a.ID = len(m.attempts) + 1
+	a.CreatedAt = time.Now().UTC()
+	a.ChosenIDs = append([]int(nil), a.ChosenIDs...)
+	a.CorrectIDs = append([]int(nil), a.CorrectIDs...)
+	m.attempts = append(m.attempts, a)
+	return nil
+}
+
+func (m *Memory) ListAttempts(ctx context.Context, exerciseID int) ([]Attempt, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var attempts []Attempt
+	for _, a := range m.attempts {
+		if exerciseID == 0 || a.ExerciseID == exerciseID {
+			attempts = append(attempts, a)
+		}
+	}
+	return attempts, nil
+}
+
+func (m *Memory) StartRun(ctx context.Context, r Run) (int64, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	r.ID = int64(len(m.runs) + 1)
+	r.StartedAt = time.Now().UTC()
+	r.EndedAt = time.Time{}
+	m.runs = append(m.runs, r)
+	return r.ID, nil
+}
+
+func (m *Memory) FinishRun(ctx context.Context, r Run) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for i := range m.runs {
+		if m.runs[i].ID == r.ID {
+			run := &m.runs[i]
+			run.EndedAt = time.Now().UTC()
+			run.ExitReason = r.ExitReason
+			run.Correct, run.Wrong = r.Correct, r.Wrong
+			run.BankHits, run.BankMisses = r.BankHits, r.BankMisses
+			run.Skipped = r.Skipped
+		}
+	}
+	return nil
+}
+
+func (m *Memory) ListRuns(ctx context.Context, moduleID int) ([]Run, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var runs []Run
+	for i := len(m.runs) - 1; i >= 0; i-- {
+		if moduleID == 0 || m.runs[i].ModuleID == moduleID {
+			runs = append(runs, m.runs[i])
+		}
+	}
+	return runs, nil
+}
+
+func (m *Memory) CreateMasterySample(ctx context.Context, s MasterySample) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	s.CreatedAt = time.Now().UTC()
+	m.mastery = append(m.mastery, s)
+	return nil
+}
+
+func (m *Memory) ListMasterySamples(ctx context.Context, moduleID int, since time.Time) ([]MasterySample, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var samples []MasterySample
+	for _, s := range m.mastery {
+		if (moduleID == 0 || s.ModuleID == moduleID) && !s.CreatedAt.Before(since) {
+			samples = append(samples, s)
+		}
+	}
+	return samples, nil
+}
+
+func (m *Memory) CreateReasoning(ctx context.Context, r Reasoning) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	r.CreatedAt = time.Now().UTC()
+	m.reasonings = append(m.reasonings, r)
+	return nil
+}
+
+func (m *Memory) GetCachedAnswer(ctx context.Context, key string) (*CachedAnswer, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	c, ok := m.cache[key]
+	if !ok || !c.ExpiresAt.After(time.Now()) {
+		return nil, nil
+	}
+	return &c, nil
+}
+
+func (m *Memory) PutCachedAnswer(ctx context.Context, c CachedAnswer) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.cache[c.Key] = c
+	now := time.Now()
+	for key, cached := range m.cache {
+		if !cached.ExpiresAt.After(now) {
+			delete(m.cache, key)
+		}
+	}
+	return nil
+}
+
+func (m *Memory) CreateDecision(ctx context.Context, d Decision) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	d.CreatedAt = time.Now().UTC()
+	m.decisions = append(m.decisions, d)
+	return nil
+}
+
+func (m *Memory) CreateMistake(ctx context.Context, mistake Mistake) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	mistake.CreatedAt = time.Now().UTC()
+	m.mistakes = append(m.mistakes, mistake)
+	return nil
+}
+
+func (m *Memory) ListTheoryChunks(ctx context.Context, moduleID int) ([]TheoryChunk, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var chunks []TheoryChunk
+	for _, c := range m.theory {
+		if c.ModuleID == moduleID {
+			chunks = append(chunks, copyChunk(c))
+		}
+	}
+	sort.Slice(chunks, func(i, j int) bool {
+		if chunks[i].ActivityID != chunks[j].ActivityID {
+			return chunks[i].ActivityID < chunks[j].ActivityID
+		}
+		return chunks[i].Position < chunks[j].Position
+	})
+	return chunks, nil
+}
+
+func (m *Memory) CreateTheoryChunks(ctx context.Context, chunks []TheoryChunk) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.putChunks(chunks)
+	return nil
+}
+
+func (m *Memory) ReplaceTheoryChunks(ctx context.Context, activityID int, chunks []TheoryChunk) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	kept := m.theory[:0]
+	for _, c := range m.theory {
+		if c.ActivityID != activityID {
+			kept = append(kept, c)
+		}
+	}
+	m.theory = kept
+	m.putChunks(chunks)
+	return nil
+}
+
+// putChunks stores chunks over ones at the same lesson position, m.mu must be held
+func (m *Memory) putChunks(chunks []TheoryChunk) {
+	for _, c := range chunks {
+		c = copyChunk(c)
+		replaced := false
+		for i, old := range m.theory {
+			if old.ActivityID == c.ActivityID && old.Position == c.Position {
+				m.theory[i] = c
+				replaced = true
+			}
+		}
+		if !replaced {
+			m.theory = append(m.theory, c)
+		}
+	}
+}
+
+// copies keep callers from changing stored options through Correct pointers
+func copyExercise(e Exercise) Exercise {
+	options := make([]AnswerOption, len(e.Options))
+	for i, o := range e.Options {
+		options[i] = copyOption(o)
+	}
+	e.Options = options
+	return e
+}
+
+func copyOption(o AnswerOption) AnswerOption {
+	if o.Correct != nil {
+		correct := *o.Correct
+		o.Correct = &correct
+	}
+	return o
+}
+
+func copyChunk(c TheoryChunk) TheoryChunk {
+	c.Embedding = append([]float32(nil), c.Embedding...)
+	return c
+}
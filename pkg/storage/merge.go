@@ -0,0 +1,61 @@
+package storage
+
+import (
+	"context"
+	"slices"
+)
+
+// outcomes of Merge
+const (
+	MergeAdded     = "added"
+	MergeUpdated   = "updated"
+	MergeUnchanged = "unchanged"
+	MergeConflict  = "conflict"
+)
+
+// Conflict is an exercise whose stored and imported right answers are both
+// known and differ, the stored ones are kept
+type Conflict struct {
+	ExerciseID int
+	Stored     []int
+	Imported   []int
+}
+
+// Merge stores exercise e coming from another bank. Verified answers win:
+// an unknown exercise is added, a stored one without known answers takes the
+// imported answers, an imported one without known answers changes nothing.
+// When both sides know different answers the stored ones are kept and the
+// conflict is returned.
+func Merge(ctx context.Context, store Store, e Exercise) (string, *Conflict, error) {
+	stored, err := store.GetExercise(ctx, e.ID)
+	if err != nil {
+		return "", nil, err
+	}
+	imported := e.CorrectIDs()
+
+	switch {
+	case stored == nil:
+		if err := store.SaveExercise(ctx, e); err != nil {
+			return "", nil, err
+		}
+		return MergeAdded, nil, nil
+	case len(imported) == 0:
+		return MergeUnchanged, nil, nil
+	}
+
+	known := stored.CorrectIDs()
+	switch {
+	case len(known) == 0:
+		if err := store.SaveExercise(ctx, e); err != nil {
+			return "", nil, err
+		}
+		if err := store.SetCorrect(ctx, e.ID, imported); err != nil {
+			return "", nil, err
+		}
+		return MergeUpdated, nil, nil
+	case SameIDs(known, imported):
+		return MergeUnchanged, nil, nil
+	default:
+		return MergeConflict, &Conflict{ExerciseID: e.ID, Stored: slices.Clone(known), Imported: imported}, nil
+	}
+}
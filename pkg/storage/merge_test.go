@@ -0,0 +1,77 @@
+package storage_test
+
+import (
+	"pkg/storage"
+	"slices"
+	"testing"
+)
+
+// answered returns exercise 1 with options 10, 11, 12 and right ones marked,
+// none are known without right
+func answered(right ...int) storage.Exercise {
+	e := exercise(1, 10, 11, 12)
+	if len(right) == 0 {
+		return e
+	}
+	for i := range e.Options {
+		correct := slices.Contains(right, e.Options[i].ID)
+		e.Options[i].Correct = &correct
+	}
+	return e
+}
+
+func TestMerge(t *testing.T) {
+	tests := []struct {
+		name     string
+		stored   *storage.Exercise
+		imported storage.Exercise
+		outcome  string
+		conflict bool
+		correct  []int
+	}{
+		{"new", nil, answered(11), storage.MergeAdded, false, []int{11}},
+		{"new without answers", nil, answered(), storage.MergeAdded, false, nil},
+		{"imported unknown", ptr(answered(10)), answered(), storage.MergeUnchanged, false, []int{10}},
+		{"stored unknown", ptr(answered()), answered(12), storage.MergeUpdated, false, []int{12}},
+		{"same answers", ptr(answered(10, 11)), answered(11, 10), storage.MergeUnchanged, false, []int{10, 11}},
+		{"conflict", ptr(answered(10)), answered(11), storage.MergeConflict, true, []int{10}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stores(t, func(t *testing.T, s storage.Store) {
+				ctx := t.Context()
+				if tt.stored != nil {
+					if err := s.SaveExercise(ctx, *tt.stored); err != nil {
+						t.Fatal(err)
+					}
+				}
+
+				outcome, conflict, err := storage.Merge(ctx, s, tt.imported)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if outcome != tt.outcome {
+					t.Errorf("outcome %q, want %q", outcome, tt.outcome)
+				}
+				if (conflict != nil) != tt.conflict {
+					t.Errorf("conflict %+v, want %v", conflict, tt.conflict)
+				}
+				if conflict != nil && (!slices.Equal(conflict.Stored, []int{10}) || !slices.Equal(conflict.Imported, []int{11})) {
+					t.Errorf("conflict %+v, want stored [10] and imported [11]", conflict)
+				}
+
+				correct, err := s.CorrectAnswers(ctx, 1)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !slices.Equal(correct, tt.correct) {
+					t.Errorf("stored answers %v, want %v", correct, tt.correct)
+				}
+			})
+		})
+	}
+}
+
+func ptr(e storage.Exercise) *storage.Exercise {
+	return &e
+}
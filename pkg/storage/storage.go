@@ -0,0 +1,276 @@
+// Package storage defines the question bank, catalog, attempts, runs and
+// solver notes store used by the runner. database.DB implements it on SQLite,
+// Memory keeps everything in process for tests and runs without -db.
+package storage
+
+import (
+	"context"
+	"time"
+)
+
+// LearnerModel marks answers given by the learner instead of a model
+const LearnerModel = "learner"
+
+type Store interface {
+	// SyncCatalog upserts subjects, courses and modules, entries missing
+	// from c are kept
+	SyncCatalog(ctx context.Context, c Catalog) (SyncResult, error)
+
+	// SaveExercise stores exercise with its options, known correctness of
+	// options is kept when the exercise is saved again
+	SaveExercise(ctx context.Context, e Exercise) error
+	// SetCorrect marks correctIDs as right options of exercise and the rest as wrong
+	SetCorrect(ctx context.Context, exerciseID int, correctIDs []int) error
+	// GetExercise returns exercise with options in platform order, nil if
+	// it was never seen
+	GetExercise(ctx context.Context, id int) (*Exercise, error)
+	// FindExercises returns exercises with the content fingerprint, most
+	// recently updated first
+	FindExercises(ctx context.Context, fingerprint string) ([]Exercise, error)
+	// ListExercises returns exercises of subject (all subjects when 0) that
+	// have a known right option
+	ListExercises(ctx context.Context, subjectID int) ([]Exercise, error)
+	// AllExercises returns every stored exercise ordered by id
+	AllExercises(ctx context.Context) ([]Exercise, error)
+	// CorrectAnswers returns ids of options known to be right
+	CorrectAnswers(ctx context.Context, exerciseID int) ([]int, error)
+
+	CreateAttempt(ctx context.Context, a Attempt) error
+	// ListAttempts returns attempts of exercise (all exercises when 0), oldest first
+	ListAttempts(ctx context.Context, exerciseID int) ([]Attempt, error)
+
+	// StartRun stores a new run and returns its id
+	StartRun(ctx context.Context, r Run) (int64, error)
+	// FinishRun sets end time, exit reason and totals of run r.ID
+	FinishRun(ctx context.Context, r Run) error
+	// ListRuns returns runs of module (all modules when 0), newest first
+	ListRuns(ctx context.Context, moduleID int) ([]Run, error)
+	CreateMasterySample(ctx context.Context, s MasterySample) error
+	// ListMasterySamples returns mastery readings of module (all modules
+	// when 0) taken after since, oldest first
+	ListMasterySamples(ctx context.Context, moduleID int, since time.Time) ([]MasterySample, error)
+
+	CreateReasoning(ctx context.Context, r Reasoning) error
+	// GetCachedAnswer returns nil when key is missing or expired
+	GetCachedAnswer(ctx context.Context, key string) (*CachedAnswer, error)
+	// PutCachedAnswer stores c over the answer with the same key and drops
+	// expired ones
+	PutCachedAnswer(ctx context.Context, c CachedAnswer) error
+	CreateDecision(ctx context.Context, d Decision) error
+	CreateMistake(ctx context.Context, m Mistake) error
+
+	// ListTheoryChunks returns chunks of module ordered by lesson and position
+	ListTheoryChunks(ctx context.Context, moduleID int) ([]TheoryChunk, error)
+	// CreateTheoryChunks stores chunks over ones at the same positions, used
+	// to update embeddings of already indexed chunks
+	CreateTheoryChunks(ctx context.Context, chunks []TheoryChunk) error
+	// ReplaceTheoryChunks stores chunks of a lesson instead of all its
+	// previous ones
+	ReplaceTheoryChunks(ctx context.Context, activityID int, chunks []TheoryChunk) error
+}
+
+// Exercise is a stored plario exercise, Content and option texts are html as
+// the platform sends them
+type Exercise struct {
+	ID      int
+	Kind    string
+	Content string
+	// Fingerprint of content text, see plario.Fingerprint
+	Fingerprint string
+	// QuestionText and AnswersText are plain text indexed for search
+	QuestionText string
+	AnswersText  string
+	Options      []AnswerOption
+
+	SubjectID int
+	CourseID  int
+	ModuleID  int
+
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+type AnswerOption struct {
+	ID   int
+	Text string
+	// Correct is nil while it is not known
+	Correct *bool
+}
+
+// Attempt is one submitted answer, Session is plario attempt id
+type Attempt struct {
+	ID         int
+	ExerciseID int
+	Session    int
+	// Model that answered, LearnerModel when the learner did
+	Model      string
+	ChosenIDs  []int
+	CorrectIDs []int
+	Correct    bool
+	CreatedAt  time.Time
+}
+
+// CorrectIDs returns ids of options known to be right
+func (e *Exercise) CorrectIDs() []int {
+	var ids []int
+	for _, o := range e.Options {
+		if o.Correct != nil && *o.Correct {
+			ids = append(ids, o.ID)
+		}
+	}
+	return ids
+}
+
+// SameIDs reports whether chosen and correct hold the same ids in any order
+func SameIDs(chosen, correct []int) bool {
+	if len(chosen) != len(correct) {
+		return false
+	}
+	seen := make(map[int]int, len(chosen))
+	for _, id := range chosen {
+		seen[id]++
+	}
+	for _, id := range correct {
+		if seen[id] == 0 {
+			return false
+		}
+		seen[id]--
+	}
+	return true
+}
+
+// why a run ended
+const (
+	ExitInterrupted = "interrupted"
+	ExitQuit        = "quit"
+	ExitMastery     = "mastery"
+	// ExitSkipped is set when an exercise plario keeps serving was left
+	// unanswered too many times
+	ExitSkipped = "skipped"
+)
+
+type Run struct {
+	ID        int64
+	StartedAt time.Time
+	// EndedAt is zero while the run goes on or when it crashed
+	EndedAt time.Time
+
+	SubjectID int
+	CourseID  int
+	ModuleID  int
+	Model     string
+	// Flags set on the command line, secrets excluded
+	Flags      map[string]string
+	ExitReason string
+
+	Correct    int
+	Wrong      int
+	BankHits   int
+	BankMisses int
+	// Skipped is number of times an exercise was left unanswered
+	Skipped int
+}
+
+type MasterySample struct {
+	RunID     int64
+	ModuleID  int
+	Mastery   float64
+	CreatedAt time.Time
+}
+
+// CatalogEntry is a subject, course or module, ParentID is subject of a
+// course and course of a module
+type CatalogEntry struct {
+	ID       int
+	Name     string
+	ParentID int
+}
+
+// Catalog is what the platform makes available to the learner
+type Catalog struct {
+	Subjects []CatalogEntry
+	Courses  []CatalogEntry
+	Modules  []CatalogEntry
+}
+
+type SyncResult struct {
+	Added     int
+	Renamed   int
+	Unchanged int
+}
+
+type Reasoning struct {
+	QuestionID int
+	Model      string
+	Reasoning  string
+	Answer     int
+	CreatedAt  time.Time
+
+	CourseID int
+	ModuleID int
+}
+
+type CachedAnswer struct {
+	Key     string
+	Model   string
+	Answer  int
+	Content string
+	// Confidence is share of samples that agreed on Answer
+	Confidence float64
+	ExpiresAt  time.Time
+}
+
+// actions a learner takes on a proposed answer in confirmation mode
+const (
+	ActionAccepted = "accepted"
+	ActionChanged  = "changed"
+	ActionSkipped  = "skipped"
+)
+
+type Decision struct {
+	QuestionID  int
+	Model       string
+	ModelAnswer int
+	// HumanAnswer is 0 when skipped
+	HumanAnswer int
+	Action      string
+	CreatedAt   time.Time
+
+	CourseID int
+	ModuleID int
+}
+
+// Mistake is a wrong first answer with texts of both options, so the
+// notebook reads without the platform
+type Mistake struct {
+	QuestionID int
+	// Question is markdown of exercise content
+	Question     string
+	ChosenAnswer int
+	ChosenText   string
+	RightAnswer  int
+	RightText    string
+	Explanation  string
+	// Model that gave the answer, LearnerModel in study mode
+	Model     string
+	CreatedAt time.Time
+
+	CourseID int
+	ModuleID int
+	// ModuleName is filled by database.ListMistakes when the module is in the catalog
+	ModuleName string
+}
+
+// TheoryChunk is a piece of a theory lesson text, Embedding is empty when
+// chunk was indexed without embeddings endpoint
+type TheoryChunk struct {
+	ActivityID int
+	Position   int
+	Content    string
+
+	Embedding      []float32
+	EmbeddingModel string
+
+	CourseID int
+	ModuleID int
+}
@@ -0,0 +1,317 @@
+package storage_test
+
+import (
+	"path/filepath"
+	"pkg/database"
+	"pkg/storage"
+	"slices"
+	"testing"
+	"time"
+)
+
+// stores runs f against every Store implementation, each gets an empty store
+func stores(t *testing.T, f func(t *testing.T, s storage.Store)) {
+	t.Run("memory", func(t *testing.T) {
+		f(t, storage.NewMemory())
+	})
+	t.Run("sqlite", func(t *testing.T) {
+		db, err := database.New(t.Context(), filepath.Join(t.TempDir(), "bank.db"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { db.Close() })
+		f(t, db)
+	})
+}
+
+func exercise(id int, options ...int) storage.Exercise {
+	e := storage.Exercise{ID: id, Kind: "quiz", Content: "<p>2+2</p>", Fingerprint: "2 + 2", SubjectID: 1, CourseID: 2, ModuleID: 3}
+	for _, o := range options {
+		e.Options = append(e.Options, storage.AnswerOption{ID: o, Text: "<p>option</p>"})
+	}
+	return e
+}
+
+func optionIDs(e *storage.Exercise) []int {
+	var ids []int
+	for _, o := range e.Options {
+		ids = append(ids, o.ID)
+	}
+	slices.Sort(ids)
+	return ids
+}
+
+func TestSaveExercise(t *testing.T) {
+	stores(t, func(t *testing.T, s storage.Store) {
+		ctx := t.Context()
+		if err := s.SaveExercise(ctx, exercise(10, 1, 2, 3)); err != nil {
+			t.Fatal(err)
+		}
+		if err := s.SetCorrect(ctx, 10, []int{2}); err != nil {
+			t.Fatal(err)
+		}
+
+		// saved again with a changed option list, correctness is not sent
+		again := exercise(10, 3, 1, 4)
+		again.Options[0].Text = "<p>changed</p>"
+		if err := s.SaveExercise(ctx, again); err != nil {
+			t.Fatal(err)
+		}
+
+		e, err := s.GetExercise(ctx, 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if e == nil {
+			t.Fatal("exercise not found")
+		}
+		if ids := optionIDs(e); !slices.Equal(ids, []int{1, 2, 3, 4}) {
+			t.Errorf("options %v, want missing ones kept and new ones added", ids)
+		}
+		if ids := e.CorrectIDs(); !slices.Equal(ids, []int{2}) {
+			t.Errorf("correct %v, want [2]", ids)
+		}
+		for _, o := range e.Options {
+			switch o.ID {
+			case 1:
+				if o.Correct == nil || *o.Correct {
+					t.Errorf("option 1 correct %v, want known wrong", o.Correct)
+				}
+			case 3:
+				if o.Text != "<p>changed</p>" {
+					t.Errorf("option 3 text %q, want updated", o.Text)
+				}
+			case 4:
+				if o.Correct != nil {
+					t.Errorf("option 4 correct %v, want unknown", *o.Correct)
+				}
+			}
+		}
+
+		// options of a stored exercise must not change through the copy
+		*e.Options[0].Correct = true
+		stored, err := s.CorrectAnswers(ctx, 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !slices.Equal(stored, []int{2}) {
+			t.Errorf("CorrectAnswers %v, want [2]", stored)
+		}
+	})
+}
+
+func TestExerciseQueries(t *testing.T) {
+	stores(t, func(t *testing.T, s storage.Store) {
+		ctx := t.Context()
+		answered := exercise(1, 10, 11)
+		other := exercise(2, 20, 21)
+		other.SubjectID = 5
+		unknown := exercise(3, 30, 31)
+		unknown.Fingerprint = "3 + 3"
+		for _, e := range []storage.Exercise{answered, other, unknown} {
+			if err := s.SaveExercise(ctx, e); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := s.SetCorrect(ctx, 1, []int{11}); err != nil {
+			t.Fatal(err)
+		}
+		if err := s.SetCorrect(ctx, 2, []int{20}); err != nil {
+			t.Fatal(err)
+		}
+
+		missing, err := s.GetExercise(ctx, 99)
+		if err != nil || missing != nil {
+			t.Errorf("GetExercise of unknown id = %v, %v", missing, err)
+		}
+
+		found, err := s.FindExercises(ctx, "2 + 2")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(found) != 2 {
+			t.Errorf("FindExercises found %d, want 2", len(found))
+		}
+
+		listed, err := s.ListExercises(ctx, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(listed) != 1 || listed[0].ID != 1 {
+			t.Errorf("ListExercises(1) = %+v, want exercise 1 only", listed)
+		}
+		listed, err = s.ListExercises(ctx, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(listed) != 2 {
+			t.Errorf("ListExercises(0) listed %d, want answered ones of all subjects", len(listed))
+		}
+
+		all, err := s.AllExercises(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var ids []int
+		for _, e := range all {
+			ids = append(ids, e.ID)
+		}
+		if !slices.Equal(ids, []int{1, 2, 3}) {
+			t.Errorf("AllExercises ids %v, want [1 2 3]", ids)
+		}
+	})
+}
+
+func TestAttemptsAndRuns(t *testing.T) {
+	stores(t, func(t *testing.T, s storage.Store) {
+		ctx := t.Context()
+		if err := s.SaveExercise(ctx, exercise(1, 10, 11)); err != nil {
+			t.Fatal(err)
+		}
+		for _, chosen := range []int{10, 11} {
+			err := s.CreateAttempt(ctx, storage.Attempt{ExerciseID: 1, Model: "m", ChosenIDs: []int{chosen}, CorrectIDs: []int{11}, Correct: chosen == 11})
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+		attempts, err := s.ListAttempts(ctx, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(attempts) != 2 || attempts[0].Correct || !attempts[1].Correct {
+			t.Errorf("attempts %+v, want wrong then right", attempts)
+		}
+
+		first, err := s.StartRun(ctx, storage.Run{ModuleID: 3, Model: "m"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		second, err := s.StartRun(ctx, storage.Run{ModuleID: 4, Model: "m"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = s.FinishRun(ctx, storage.Run{ID: first, ExitReason: storage.ExitSkipped, Correct: 2, Wrong: 1, BankHits: 1, Skipped: 3})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		runs, err := s.ListRuns(ctx, 3)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(runs) != 1 {
+			t.Fatalf("runs of module 3: %+v", runs)
+		}
+		r := runs[0]
+		if r.ExitReason != storage.ExitSkipped || r.Correct != 2 || r.Wrong != 1 || r.BankHits != 1 || r.Skipped != 3 || r.EndedAt.IsZero() {
+			t.Errorf("finished run %+v", r)
+		}
+		runs, err = s.ListRuns(ctx, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(runs) != 2 || runs[0].ID != second {
+			t.Errorf("all runs %+v, want newest first", runs)
+		}
+
+		if err := s.CreateMasterySample(ctx, storage.MasterySample{RunID: first, ModuleID: 3, Mastery: 0.5}); err != nil {
+			t.Fatal(err)
+		}
+		samples, err := s.ListMasterySamples(ctx, 3, time.Now().Add(-time.Hour))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(samples) != 1 || samples[0].Mastery != 0.5 {
+			t.Errorf("mastery samples %+v", samples)
+		}
+	})
+}
+
+func TestCachedAnswer(t *testing.T) {
+	stores(t, func(t *testing.T, s storage.Store) {
+		ctx := t.Context()
+		err := s.PutCachedAnswer(ctx, storage.CachedAnswer{Key: "old", Model: "m", Answer: 1, ExpiresAt: time.Now().Add(-time.Minute)})
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = s.PutCachedAnswer(ctx, storage.CachedAnswer{Key: "new", Model: "m", Answer: 2, Confidence: 0.5, ExpiresAt: time.Now().Add(time.Hour)})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		c, err := s.GetCachedAnswer(ctx, "old")
+		if err != nil || c != nil {
+			t.Errorf("expired answer = %+v, %v", c, err)
+		}
+		c, err = s.GetCachedAnswer(ctx, "new")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if c == nil || c.Answer != 2 || c.Confidence != 0.5 {
+			t.Errorf("cached answer %+v", c)
+		}
+		c, err = s.GetCachedAnswer(ctx, "missing")
+		if err != nil || c != nil {
+			t.Errorf("missing answer = %+v, %v", c, err)
+		}
+	})
+}
+
+func TestTheoryChunks(t *testing.T) {
+	stores(t, func(t *testing.T, s storage.Store) {
+		ctx := t.Context()
+		chunk := func(activity, position int, content string) storage.TheoryChunk {
+			return storage.TheoryChunk{ActivityID: activity, Position: position, Content: content, CourseID: 2, ModuleID: 3}
+		}
+		err := s.CreateTheoryChunks(ctx, []storage.TheoryChunk{chunk(8, 1, "b"), chunk(7, 0, "a"), chunk(8, 0, "c"), chunk(8, 2, "d")})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// embeddings are stored over the chunk at the same position
+		embedded := chunk(7, 0, "a")
+		embedded.Embedding, embedded.EmbeddingModel = []float32{0.5, -1}, "e"
+		if err := s.CreateTheoryChunks(ctx, []storage.TheoryChunk{embedded}); err != nil {
+			t.Fatal(err)
+		}
+		// a shorter lesson leaves nothing of the longer one
+		if err := s.ReplaceTheoryChunks(ctx, 8, []storage.TheoryChunk{chunk(8, 0, "e")}); err != nil {
+			t.Fatal(err)
+		}
+
+		chunks, err := s.ListTheoryChunks(ctx, 3)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var contents []string
+		for _, c := range chunks {
+			contents = append(contents, c.Content)
+		}
+		if !slices.Equal(contents, []string{"a", "e"}) {
+			t.Fatalf("chunks %v, want [a e]", contents)
+		}
+		if !slices.Equal(chunks[0].Embedding, []float32{0.5, -1}) || chunks[0].EmbeddingModel != "e" {
+			t.Errorf("embedding %v of %q", chunks[0].Embedding, chunks[0].EmbeddingModel)
+		}
+
+		other, err := s.ListTheoryChunks(ctx, 4)
+		if err != nil || len(other) != 0 {
+			t.Errorf("chunks of another module = %v, %v", other, err)
+		}
+	})
+}
+
+func TestSolverNotes(t *testing.T) {
+	stores(t, func(t *testing.T, s storage.Store) {
+		ctx := t.Context()
+		if err := s.CreateReasoning(ctx, storage.Reasoning{QuestionID: 1, Model: "m", Reasoning: "because", Answer: 10, ModuleID: 3}); err != nil {
+			t.Fatal(err)
+		}
+		if err := s.CreateDecision(ctx, storage.Decision{QuestionID: 1, Model: "m", ModelAnswer: 10, Action: storage.ActionSkipped, ModuleID: 3}); err != nil {
+			t.Fatal(err)
+		}
+		if err := s.CreateMistake(ctx, storage.Mistake{QuestionID: 1, ChosenAnswer: 10, RightAnswer: 11, Model: storage.LearnerModel, ModuleID: 3}); err != nil {
+			t.Fatal(err)
+		}
+	})
+}
@@ -0,0 +1,178 @@
+package textsim
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"math"
+	"strings"
+	"unicode"
+)
+
+// Normalize lowercases s and collapses whitespace, so texts differing only
+// in case and spacing compare equal
+func Normalize(s string) string {
+	return strings.Join(strings.Fields(strings.ToLower(s)), " ")
+}
+
+// Tokens lowercases s and splits it into words, numbers and operators.
+// Latex commands like \frac are kept as separate tokens, so are arithmetic
+// and relational symbols, signs, ^/_ markers and brackets: x+2 and x-2 must
+// differ, so must (x+1)^2 and x+1^2. Braces around a single token are
+// dropped, x^{2} is written x^2 as well.
+func Tokens(s string) []string {
+	var tokens []string
+	var b strings.Builder
+
+	flush := func() {
+		if b.Len() > 0 {
+			tokens = append(tokens, b.String())
+			b.Reset()
+		}
+	}
+
+	for _, r := range strings.ToLower(s) {
+		switch {
+		case r == '\\':
+			flush()
+			b.WriteRune(r)
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			b.WriteRune(r)
+		case operator(r):
+			flush()
+			if r == '−' {
+				r = '-'
+			}
+			tokens = append(tokens, string(r))
+		default:
+			flush()
+		}
+	}
+	flush()
+
+	return ungroup(tokens)
+}
+
+// operator reports whether r changes what a formula means, brackets do as
+// they group what follows ^, \sqrt or a sign
+func operator(r rune) bool {
+	return strings.ContainsRune("+-=<>^_*/()[]{}", r) || unicode.Is(unicode.Sm, r)
+}
+
+// ungroup drops braces around a single token, tex reads {2} as 2
+func ungroup(tokens []string) []string {
+	kept := tokens[:0]
+	for i := 0; i < len(tokens); i++ {
+		if tokens[i] == "{" && i+2 < len(tokens) && tokens[i+2] == "}" && !bracket(tokens[i+1]) {
+			kept = append(kept, tokens[i+1])
+			i += 2
+			continue
+		}
+		kept = append(kept, tokens[i])
+	}
+	return kept
+}
+
+func bracket(t string) bool {
+	return len(t) == 1 && strings.Contains("()[]{}", t)
+}
+
+// Bigrams returns pairs of adjacent tokens with text start and end as
+// neighbours, vectors of them tell apart texts of the same tokens in
+// another order
+func Bigrams(tokens []string) []string {
+	if len(tokens) == 0 {
+		return nil
+	}
+	bigrams := make([]string, 0, len(tokens)+1)
+	prev := ""
+	for _, t := range tokens {
+		bigrams = append(bigrams, prev+" "+t)
+		prev = t
+	}
+	return append(bigrams, prev+" ")
+}
+
+// Vector is a term frequency vector
+type Vector map[string]float64
+
+func NewVector(tokens []string) Vector {
+	v := make(Vector, len(tokens))
+	for _, t := range tokens {
+		v[t]++
+	}
+	return v
+}
+
+func (v Vector) norm() float64 {
+	var sum float64
+	for _, f := range v {
+		sum += f * f
+	}
+	return math.Sqrt(sum)
+}
+
+// Cosine similarity in [0, 1]
+func (v Vector) Cosine(o Vector) float64 {
+	if len(v) == 0 || len(o) == 0 {
+		return 0
+	}
+	if len(o) < len(v) {
+		v, o = o, v
+	}
+
+	var dot float64
+	for t, f := range v {
+		dot += f * o[t]
+	}
+	return dot / (v.norm() * o.norm())
+}
+
+// Similarity of two texts by cosine of their term frequencies, word order
+// does not matter
+func Similarity(a, b string) float64 {
+	return NewVector(Tokens(a)).Cosine(NewVector(Tokens(b)))
+}
+
+// SequenceSimilarity of two texts by cosine of their token bigrams, same
+// tokens in another order score below 1
+func SequenceSimilarity(a, b string) float64 {
+	return NewVector(Bigrams(Tokens(a))).Cosine(NewVector(Bigrams(Tokens(b))))
+}
+
+// latex commands that render the same, a fingerprint should not tell them apart
+var latexAliases = map[string]string{
+	`\dfrac`:        `\frac`,
+	`\tfrac`:        `\frac`,
+	`\le`:           `\leq`,
+	`\ge`:           `\geq`,
+	`\ne`:           `\neq`,
+	`\left`:         "",
+	`\right`:        "",
+	`\displaystyle`: "",
+}
+
+// FingerprintVersion changes whenever Fingerprint of a text may change,
+// stored fingerprints of an older version must be computed again
+const FingerprintVersion = 3
+
+// Fingerprint is a hash of s tokens, texts differing only in case, spacing,
+// punctuation, braces around a single token and equivalent latex commands
+// get the same one.
+// Empty when s has no tokens.
+func Fingerprint(s string) string {
+	var tokens []string
+	for _, t := range Tokens(s) {
+		if alias, ok := latexAliases[t]; ok {
+			t = alias
+		}
+		if t != "" {
+			tokens = append(tokens, t)
+		}
+	}
+	if len(tokens) == 0 {
+		return ""
+	}
+
+	sum := sha256.Sum256([]byte(strings.Join(tokens, " ")))
+	return hex.EncodeToString(sum[:])
+}
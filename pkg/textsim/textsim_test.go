@@ -0,0 +1,110 @@
+package textsim
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestTokens(t *testing.T) {
+	tests := []struct {
+		in   string
+		want []string
+	}{
+		{"Solve x+2=5", []string{"solve", "x", "+", "2", "=", "5"}},
+		{"x^2 - y_1", []string{"x", "^", "2", "-", "y", "_", "1"}},
+		{"a ≤ b, −1", []string{"a", "≤", "b", "-", "1"}},
+		{`\frac{1}{2}`, []string{`\frac`, "1", "2"}},
+		{"(x), [y]!", []string{"(", "x", ")", "[", "y", "]"}},
+		{`\sqrt{x+1}`, []string{`\sqrt`, "{", "x", "+", "1", "}"}},
+		{`x^{2}_{1}`, []string{"x", "^", "2", "_", "1"}},
+		{"", nil},
+	}
+	for _, tt := range tests {
+		if got := Tokens(tt.in); !slices.Equal(got, tt.want) {
+			t.Errorf("Tokens(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestFingerprint(t *testing.T) {
+	differ := []struct{ a, b string }{
+		{"Solve x+2=5", "Solve x-2=5"},
+		{"x^2", "x_2"},
+		{"-1", "1"},
+		{"x < y", "x > y"},
+		{"a = b", "a ≠ b"},
+		{"2*3", "2/3"},
+		{"x-y", "y-x"},
+		{"(x+1)^2", "x+1^2"},
+		{`\sqrt{x+1}`, `\sqrt{x}+1`},
+		{"[0, 1)", "(0, 1]"},
+	}
+	for _, tt := range differ {
+		if Fingerprint(tt.a) == Fingerprint(tt.b) {
+			t.Errorf("%q and %q have the same fingerprint", tt.a, tt.b)
+		}
+	}
+
+	same := []struct{ a, b string }{
+		{"Solve  X+2=5", "solve x + 2 = 5"},
+		{"Solve x+2=5.", "Solve x+2=5"},
+		{`x^{2}`, "x^2"},
+		{`\dfrac{1}{2}`, `\frac{1}{2}`},
+		{`\left(x+1\right)`, "(x+1)"},
+		{`a \le b`, `a \leq b`},
+		{"5 − 3", "5 - 3"},
+	}
+	for _, tt := range same {
+		if Fingerprint(tt.a) != Fingerprint(tt.b) {
+			t.Errorf("%q and %q have different fingerprints", tt.a, tt.b)
+		}
+	}
+
+	if Fingerprint(" .,") != "" {
+		t.Error("fingerprint of text without tokens is not empty")
+	}
+}
+
+func TestSequenceSimilarity(t *testing.T) {
+	tests := []struct {
+		a, b      string
+		bag, want func(float64) bool
+	}{
+		// swapped operands are the same bag of tokens
+		{"Найдите x-y", "Найдите y-x", eq(1), lt(0.95)},
+		{"Решите 5=x+2", "Решите x+2=5", eq(1), lt(0.95)},
+		{"Найдите x-y", "найдите  x - y", eq(1), eq(1)},
+		{"Найдите x-y", "Найдите x+y", lt(1), lt(0.95)},
+		{"", "x", eq(0), eq(0)},
+	}
+	for _, tt := range tests {
+		if got := Similarity(tt.a, tt.b); !tt.bag(got) {
+			t.Errorf("Similarity(%q, %q) = %.3f", tt.a, tt.b, got)
+		}
+		if got := SequenceSimilarity(tt.a, tt.b); !tt.want(got) {
+			t.Errorf("SequenceSimilarity(%q, %q) = %.3f", tt.a, tt.b, got)
+		}
+	}
+}
+
+func eq(v float64) func(float64) bool {
+	return func(x float64) bool { return x > v-1e-9 && x < v+1e-9 }
+}
+
+func lt(v float64) func(float64) bool {
+	return func(x float64) bool { return x < v }
+}
+
+func TestBigrams(t *testing.T) {
+	tokens := []string{"x", "-", "y"}
+	want := []string{" x", "x -", "- y", "y "}
+	if got := Bigrams(tokens); !slices.Equal(got, want) {
+		t.Errorf("Bigrams = %q, want %q", got, want)
+	}
+	if !slices.Equal(tokens, []string{"x", "-", "y"}) {
+		t.Errorf("tokens changed to %q", tokens)
+	}
+	if Bigrams(nil) != nil {
+		t.Error("bigrams of no tokens")
+	}
+}